@@ -17,7 +17,7 @@ if [ $# -eq 0 ]; then
 fi
 
 # code will be compiled in this container
-BUILD_CONTAINER=golang:1.8.3-alpine
+BUILD_CONTAINER=golang:1.18-alpine
 
 DOCKER_TMP=docker-build-tmp
 
@@ -45,7 +45,7 @@ echo "nobody:x:65534:65534:Nobody:/:" > ${DOCKER_TMP}/etc/passwd
 
 for i in "$@"
 do
-	docker run -e "GOBIN=/usr/src/go/src/github.com/GeoNet/${CWD}/${DOCKER_TMP}" -e "GOPATH=/usr/src/go" -e "CGO_ENABLED=0" -e "GOOS=linux" -e "BUILD=$BUILD" --rm \
+	docker run -e "GO111MODULE=off" -e "GOBIN=/usr/src/go/src/github.com/GeoNet/${CWD}/${DOCKER_TMP}" -e "GOPATH=/usr/src/go" -e "CGO_ENABLED=0" -e "GOOS=linux" -e "BUILD=$BUILD" --rm \
 		-v "$PWD":/usr/src/go/src/github.com/GeoNet/${CWD} \
 		-w /usr/src/go/src/github.com/GeoNet/${CWD} ${BUILD_CONTAINER} \
 		go install -a -ldflags "-X main.Prefix=${i}/${VERSION}" -installsuffix cgo ./cmd/${i}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"github.com/GeoNet/weft"
+	"net/url"
+	"strconv"
+)
+
+// weekOffset is the offset in seconds from the Unix epoch (a Thursday) to the following Monday.
+// Weekly intervals start on Monday.
+const weekOffset = 4 * 24 * 60 * 60
+
+// aggregates are the SQL expressions for the value and error for each aggregate function.
+// min, max, first, and last use the error of the selected observation.
+var aggregates = map[string]string{
+	"mean":   `avg(value) AS value, avg(error) AS error`,
+	"median": `percentile_cont(0.5) WITHIN GROUP (ORDER BY value) AS value, avg(error) AS error`,
+	"min":    `(array_agg(value ORDER BY value ASC))[1] AS value, (array_agg(error ORDER BY value ASC))[1] AS error`,
+	"max":    `(array_agg(value ORDER BY value DESC))[1] AS value, (array_agg(error ORDER BY value DESC))[1] AS error`,
+	"count":  `count(*)::float8 AS value, 0::float8 AS error`,
+	"first":  `(array_agg(value ORDER BY time ASC))[1] AS value, (array_agg(error ORDER BY time ASC))[1] AS error`,
+	"last":   `(array_agg(value ORDER BY time DESC))[1] AS value, (array_agg(error ORDER BY time DESC))[1] AS error`,
+}
+
+/*
+aggQ is for resampling observations into fixed intervals.  The zero value
+does not aggregate.  Intervals are either a fixed number of seconds or
+a number of calendar months.  Buckets are aligned to the Unix epoch (weeks
+start on Monday).  Month buckets are aligned to multiples of months from
+January of year 0 so they start in January each year when months divides 12.
+*/
+type aggQ struct {
+	interval string // the interval as given in the query e.g., 1d
+	fn       string // one of the keys in aggregates.
+	seconds  int64
+	offset   int64
+	months   int
+	dateOnly bool // true if the bucket always starts at midnight.
+}
+
+/*
+getAggregate returns the aggQ for the interval and aggregate query parameters.
+interval is a positive integer followed by one of m (minutes), h, d, w, M (months), or y.
+aggregate defaults to mean and can only be used with interval.
+*/
+func getAggregate(v url.Values) (aggQ, *weft.Result) {
+	var a aggQ
+
+	if v.Get("interval") == "" {
+		if v.Get("aggregate") != "" {
+			return a, weft.BadRequest("aggregate can only be used with interval.")
+		}
+		return a, &weft.StatusOK
+	}
+
+	a.interval = v.Get("interval")
+	a.fn = v.Get("aggregate")
+
+	if a.fn == "" {
+		a.fn = "mean"
+	}
+
+	if _, ok := aggregates[a.fn]; !ok {
+		return aggQ{}, weft.BadRequest("invalid aggregate query param.")
+	}
+
+	n, err := strconv.Atoi(a.interval[:len(a.interval)-1])
+	if err != nil || n <= 0 || n > 100000 {
+		return aggQ{}, weft.BadRequest("invalid interval query param.")
+	}
+
+	switch a.interval[len(a.interval)-1] {
+	case 'm':
+		a.seconds = int64(n) * 60
+	case 'h':
+		a.seconds = int64(n) * 60 * 60
+	case 'd':
+		a.seconds = int64(n) * 24 * 60 * 60
+		a.dateOnly = true
+	case 'w':
+		a.seconds = int64(n) * 7 * 24 * 60 * 60
+		a.offset = weekOffset
+		a.dateOnly = true
+	case 'M':
+		a.months = n
+		a.dateOnly = true
+	case 'y':
+		a.months = n * 12
+		a.dateOnly = true
+	default:
+		return aggQ{}, weft.BadRequest("invalid interval query param.")
+	}
+
+	return a, &weft.StatusOK
+}
+
+// on is true if a aggregates observations.
+func (a aggQ) on() bool {
+	return a.fn != ""
+}
+
+// bucket returns an SQL expression for the start of the interval containing time.
+func (a aggQ) bucket() string {
+	if a.months > 0 {
+		return fmt.Sprintf(`((date_trunc('month', time AT TIME ZONE 'UTC') -
+			(((extract(year from time AT TIME ZONE 'UTC') * 12 + extract(month from time AT TIME ZONE 'UTC') - 1)::int %% %d) * interval '1 month'))
+			AT TIME ZONE 'UTC')`, a.months)
+	}
+
+	return fmt.Sprintf(`to_timestamp(floor((extract(epoch from time) - %d) / %d) * %d + %d)`, a.offset, a.seconds, a.seconds, a.offset)
+}
+
+// values returns the SQL select expressions for the aggregated value and error.
+func (a aggQ) values() string {
+	return aggregates[a.fn]
+}
+
+/*
+from returns the SQL FROM clause source of observations from table for the WHERE clause where.
+If a is on then the source is the aggregated observations with columns time, value, and error.
+*/
+func (a aggQ) from(table, where string) string {
+	if !a.on() {
+		return table + `
+		` + where
+	}
+
+	return `(SELECT ` + a.bucket() + ` AS time, ` + a.values() + ` FROM ` + table + `
+		` + where + `
+		GROUP BY 1) AS obs`
+}
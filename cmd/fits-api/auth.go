@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"github.com/GeoNet/weft"
+	"log"
+	"net/http"
+	"os"
+)
+
+// maxWriteBytes limits the size of request bodies for write requests.
+const maxWriteBytes = 32 << 20
+
+var unauthorized = weft.Result{Ok: false, Code: http.StatusUnauthorized, Msg: "unauthorized"}
+
+/*
+makeHandlerWrite executes f for requests that change data in the DB.
+Requests must use basic auth with the user and key set in the env var
+FITS_USER and FITS_KEY.  If FITS_KEY is not set all write requests are refused.
+
+Unlike weft.MakeHandlerAPI a non nil bytes.Buffer is passed to f for all
+request methods so that f can write a response to the client e.g., a report
+of the rows that were added.
+*/
+func makeHandlerWrite(f weft.RequestHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var res *weft.Result
+		var b bytes.Buffer
+
+		switch authorised(r) {
+		case true:
+			r.Body = http.MaxBytesReader(w, r.Body, maxWriteBytes)
+			res = f(r, w.Header(), &b)
+		case false:
+			w.Header().Set("WWW-Authenticate", `Basic realm="fits"`)
+			res = &unauthorized
+		}
+
+		weft.WriteBytes(w, r, res, &b, false)
+		res.Count()
+
+		if res.Code != http.StatusOK {
+			log.Printf("status: %d serving %s %s", res.Code, r.Method, r.RequestURI)
+		}
+	}
+}
+
+// authorised returns true if r has basic auth credentials matching FITS_USER and FITS_KEY.
+func authorised(r *http.Request) bool {
+	key := os.Getenv("FITS_KEY")
+	if key == "" {
+		return false
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(user), []byte(os.Getenv("FITS_USER"))) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(key)) == 1
+}
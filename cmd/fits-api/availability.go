@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/GeoNet/fits/internal/ts"
+	"github.com/GeoNet/weft"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+availability is the periods with data and the gaps between them.  A gap is
+a time longer than the cadence without observations.
+*/
+type availability struct {
+	Start          *time.Time // the start of the query range.  null if not set.
+	End            time.Time  // the end of the query range or now.
+	CadenceSeconds float64
+	Count          int
+	Periods        []period
+	Gaps           []gap
+}
+
+type period struct {
+	Start time.Time
+	End   time.Time
+}
+
+/*
+observationAvailability reports the periods with data and the gaps for a site and type.
+Returns JSON or an SVG timeline depending on the Accept header.
+If the cadence query parameter is not set then the cadence is twice the median time between observations.
+*/
+func observationAvailability(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID", "typeID"}, []string{"methodID", "days", "start", "end", "cadence"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	var s siteQ
+	var t typeQ
+	var start, end time.Time
+	var cadence time.Duration
+	var res *weft.Result
+
+	if start, end, res = getTimeRange(v); !res.Ok {
+		return res
+	}
+
+	if cadence, res = getCadence(v); !res.Ok {
+		return res
+	}
+
+	if t, res = getType(v); !res.Ok {
+		return res
+	}
+
+	if s, res = getSite(v); !res.Ok {
+		return res
+	}
+
+	if v.Get("methodID") != "" {
+		if res = validTypeMethod(t.typeID, v.Get("methodID")); !res.Ok {
+			return res
+		}
+	}
+
+	q := obsQ{
+		networkID: s.networkID,
+		siteID:    s.siteID,
+		typeID:    t.typeID,
+		methodID:  v.Get("methodID"),
+		start:     start,
+		end:       end,
+	}
+
+	a, err := loadAvailability(q, cadence)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	switch r.Header.Get("Accept") {
+	case svg:
+		h.Set("Content-Type", svg)
+
+		var p plt
+
+		p.SetTitle(fmt.Sprintf("%s (%s) - %s availability", s.siteID, s.name, t.description))
+
+		if a.Start != nil {
+			p.SetXAxis(*a.Start, a.End)
+		}
+
+		for _, d := range a.Periods {
+			p.AddSpan(ts.Span{Start: d.Start, End: d.End})
+		}
+
+		for _, g := range a.Gaps {
+			p.AddSpan(ts.Span{Start: g.Start, End: g.End, Gap: true})
+		}
+
+		if err = ts.Availability.Draw(p.Plot, ts.Layout{}, b); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+	default:
+		h.Set("Content-Type", v1JSON)
+
+		by, err := json.Marshal(a)
+		if err != nil {
+			return weft.InternalServerError(err)
+		}
+
+		b.Write(by)
+	}
+
+	return &weft.StatusOK
+}
+
+// cadenceUnits are the units for the cadence query parameter.
+var cadenceUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': time.Hour * 24,
+	'w': time.Hour * 24 * 7,
+}
+
+// maxCadence is the largest cadence that can be used.  Longer cadences would overflow time.Duration.
+const maxCadence = time.Duration(math.MaxInt64)
+
+/*
+getCadence returns the cadence query parameter.  A positive integer followed by one of m (minutes), h, d, or w.
+Returns 0 if not set.
+*/
+func getCadence(v url.Values) (time.Duration, *weft.Result) {
+	c := v.Get("cadence")
+
+	if c == "" {
+		return 0, &weft.StatusOK
+	}
+
+	u, ok := cadenceUnits[c[len(c)-1]]
+	if !ok {
+		return 0, weft.BadRequest("invalid cadence query param.")
+	}
+
+	n, err := strconv.Atoi(c[:len(c)-1])
+	if err != nil || n <= 0 || n > 100000 {
+		return 0, weft.BadRequest("invalid cadence query param.")
+	}
+
+	if time.Duration(n) > maxCadence/u {
+		return 0, weft.BadRequest("cadence query param is too long.")
+	}
+
+	return time.Duration(n) * u, &weft.StatusOK
+}
+
+/*
+loadAvailability finds the periods with data and the gaps longer than cadence for
+observations matching q.  If cadence is 0 twice the median time between observations is used.
+Gaps at the start and end of the query range are included.
+*/
+func loadAvailability(q obsQ, cadence time.Duration) (availability, error) {
+	a := availability{
+		End:     q.end,
+		Periods: []period{},
+		Gaps:    []gap{},
+	}
+
+	if !q.start.IsZero() {
+		a.Start = &q.start
+	}
+
+	if a.End.IsZero() {
+		a.End = time.Now().UTC()
+	}
+
+	where, args := q.where()
+
+	var first, last *time.Time
+
+	if err := db.QueryRow(`SELECT count(*), min(time), max(time) FROM fits.observation
+		`+where, args...).Scan(&a.Count, &first, &last); err != nil {
+		return a, err
+	}
+
+	a.CadenceSeconds = cadence.Seconds()
+
+	if a.Count == 0 {
+		a.setPeriods(time.Time{}, time.Time{}, nil)
+		return a, nil
+	}
+
+	if cadence == 0 {
+		if err := db.QueryRow(`SELECT COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY d), 0) * 2 FROM
+			(SELECT extract(epoch from time - lag(time) OVER (ORDER BY time)) AS d FROM fits.observation
+			`+where+`) AS g WHERE d > 0`, args...).Scan(&a.CadenceSeconds); err != nil {
+			return a, err
+		}
+	}
+
+	var gaps []gap
+
+	if a.CadenceSeconds > 0 {
+		rows, err := db.Query(`SELECT prev, time FROM
+			(SELECT time, lag(time) OVER (ORDER BY time) AS prev FROM fits.observation
+			`+where+`) AS g
+			WHERE extract(epoch from time - prev) > `+fmt.Sprintf("$%d", len(args)+1)+`
+			ORDER BY time ASC`, append(args, a.CadenceSeconds)...)
+		if err != nil {
+			return a, err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var g gap
+
+			if err = rows.Scan(&g.Start, &g.End); err != nil {
+				return a, err
+			}
+
+			gaps = append(gaps, g)
+		}
+
+		if err = rows.Err(); err != nil {
+			return a, err
+		}
+	}
+
+	a.setPeriods(*first, *last, gaps)
+
+	return a, nil
+}
+
+/*
+setPeriods sets the periods with data and the gaps for observations from first to last.
+gaps are the times longer than the cadence between consecutive observations, in time order.
+Gaps longer than the cadence at the start and end of the query range are added.  If
+there are no observations the whole query range is a gap.
+*/
+func (a *availability) setPeriods(first, last time.Time, gaps []gap) {
+	a.Periods = []period{}
+	a.Gaps = []gap{}
+
+	addGap := func(s, e time.Time) {
+		a.Gaps = append(a.Gaps, gap{Start: s, End: e, Seconds: e.Sub(s).Seconds()})
+	}
+
+	if a.Count == 0 {
+		if a.Start != nil {
+			addGap(*a.Start, a.End)
+		}
+		return
+	}
+
+	if a.Start != nil && a.CadenceSeconds > 0 && first.Sub(*a.Start).Seconds() > a.CadenceSeconds {
+		addGap(*a.Start, first)
+	}
+
+	// periods are between the gaps within the data.
+	p := period{Start: first}
+
+	for _, g := range gaps {
+		addGap(g.Start, g.End)
+
+		p.End = g.Start
+		a.Periods = append(a.Periods, p)
+		p = period{Start: g.End}
+	}
+
+	p.End = last
+	a.Periods = append(a.Periods, p)
+
+	if a.CadenceSeconds > 0 && a.End.Sub(last).Seconds() > a.CadenceSeconds {
+		addGap(last, a.End)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetCadence(t *testing.T) {
+	for _, c := range []struct {
+		id       string
+		cadence  string
+		expected time.Duration
+		ok       bool
+	}{
+		{"not set", "", 0, true},
+		{"minutes", "10m", 10 * time.Minute, true},
+		{"hours", "2h", 2 * time.Hour, true},
+		{"days", "100000d", 100000 * 24 * time.Hour, true},
+		{"weeks", "2w", 14 * 24 * time.Hour, true},
+		{"weeks max", "15250w", 15250 * 7 * 24 * time.Hour, true},
+		{"weeks overflow", "100000w", 0, false},
+		{"unit", "2y", 0, false},
+		{"no number", "w", 0, false},
+		{"zero", "0d", 0, false},
+		{"negative", "-1d", 0, false},
+		{"too many", "100001m", 0, false},
+	} {
+		d, res := getCadence(url.Values{"cadence": []string{c.cadence}})
+
+		if res.Ok != c.ok {
+			t.Errorf("%s: expected ok %t got %t", c.id, c.ok, res.Ok)
+			continue
+		}
+
+		if d != c.expected {
+			t.Errorf("%s: expected %s got %s", c.id, c.expected, d)
+		}
+	}
+}
+
+func TestSetPeriods(t *testing.T) {
+	d := func(day int) time.Time {
+		return time.Date(2000, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+	start := d(1)
+
+	for _, c := range []struct {
+		id          string
+		start       *time.Time
+		count       int
+		cadence     float64
+		first, last time.Time
+		gaps        []gap // within the data
+		periods     []period
+		expected    []gap
+	}{
+		{id: "no data", start: &start, count: 0, cadence: 86400,
+			expected: []gap{{Start: d(1), End: d(20)}}},
+		{id: "no data no start", count: 0, cadence: 86400},
+		{id: "continuous", start: &start, count: 10, cadence: 2 * 86400, first: d(2), last: d(19),
+			periods: []period{{Start: d(2), End: d(19)}}},
+		{id: "gaps at the ends", start: &start, count: 10, cadence: 86400, first: d(5), last: d(15),
+			periods:  []period{{Start: d(5), End: d(15)}},
+			expected: []gap{{Start: d(1), End: d(5)}, {Start: d(15), End: d(20)}}},
+		{id: "gaps within", start: &start, count: 10, cadence: 2 * 86400, first: d(2), last: d(19),
+			gaps:     []gap{{Start: d(4), End: d(8)}, {Start: d(10), End: d(14)}},
+			periods:  []period{{Start: d(2), End: d(4)}, {Start: d(8), End: d(10)}, {Start: d(14), End: d(19)}},
+			expected: []gap{{Start: d(4), End: d(8)}, {Start: d(10), End: d(14)}}},
+		{id: "gaps everywhere", start: &start, count: 10, cadence: 86400, first: d(5), last: d(15),
+			gaps:     []gap{{Start: d(8), End: d(10)}},
+			periods:  []period{{Start: d(5), End: d(8)}, {Start: d(10), End: d(15)}},
+			expected: []gap{{Start: d(1), End: d(5)}, {Start: d(8), End: d(10)}, {Start: d(15), End: d(20)}}},
+		{id: "no start", count: 10, cadence: 86400, first: d(5), last: d(15),
+			periods:  []period{{Start: d(5), End: d(15)}},
+			expected: []gap{{Start: d(15), End: d(20)}}},
+		{id: "single observation", start: &start, count: 1, first: d(5), last: d(5),
+			periods: []period{{Start: d(5), End: d(5)}}},
+	} {
+		a := availability{Start: c.start, End: d(20), Count: c.count, CadenceSeconds: c.cadence}
+
+		a.setPeriods(c.first, c.last, c.gaps)
+
+		if len(a.Periods) != len(c.periods) {
+			t.Errorf("%s: expected periods %v got %v", c.id, c.periods, a.Periods)
+		} else {
+			for i := range a.Periods {
+				if !a.Periods[i].Start.Equal(c.periods[i].Start) || !a.Periods[i].End.Equal(c.periods[i].End) {
+					t.Errorf("%s: expected periods %v got %v", c.id, c.periods, a.Periods)
+					break
+				}
+			}
+		}
+
+		if len(a.Gaps) != len(c.expected) {
+			t.Errorf("%s: expected gaps %v got %v", c.id, c.expected, a.Gaps)
+			continue
+		}
+
+		for i := range a.Gaps {
+			g := a.Gaps[i]
+			if !g.Start.Equal(c.expected[i].Start) || !g.End.Equal(c.expected[i].End) || g.Seconds != g.End.Sub(g.Start).Seconds() {
+				t.Errorf("%s: expected gaps %v got %v", c.id, c.expected, a.Gaps)
+				break
+			}
+		}
+	}
+}
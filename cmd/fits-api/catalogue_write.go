@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/GeoNet/weft"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+// unitW, typeW, and methodW are for adding to the unit, type, and method catalogues.
+type unitW struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+type typeW struct {
+	TypeID      string `json:"typeID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Unit        string `json:"unit"` // the unit symbol
+}
+
+type methodW struct {
+	MethodID    string `json:"methodID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"`
+}
+
+func unitAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var u unitW
+
+	if res := decodeJSON(r, &u); !res.Ok {
+		return res
+	}
+
+	switch {
+	case u.Symbol == "":
+		return weft.BadRequest("missing symbol")
+	case u.Name == "":
+		return weft.BadRequest("missing name")
+	}
+
+	if _, err := dbW.Exec(`INSERT INTO fits.unit(symbol, name) VALUES ($1, $2)`, u.Symbol, u.Name); err != nil {
+		return insertError(err, "unit "+u.Symbol+" already exists")
+	}
+
+	return writeJSON(u, h, b)
+}
+
+func typeAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var t typeW
+
+	if res := decodeJSON(r, &t); !res.Ok {
+		return res
+	}
+
+	switch {
+	case t.TypeID == "":
+		return weft.BadRequest("missing typeID")
+	case t.Name == "":
+		return weft.BadRequest("missing name")
+	case t.Description == "":
+		return weft.BadRequest("missing description")
+	case t.Unit == "":
+		return weft.BadRequest("missing unit")
+	}
+
+	res, err := dbW.Exec(`INSERT INTO fits.type(typeID, name, description, unitPK)
+		SELECT $1, $2, $3, unitPK FROM fits.unit WHERE symbol = $4`, t.TypeID, t.Name, t.Description, t.Unit)
+	if err != nil {
+		return insertError(err, "typeID "+t.TypeID+" already exists")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if n != 1 {
+		return weft.BadRequest("unknown unit " + t.Unit)
+	}
+
+	return writeJSON(t, h, b)
+}
+
+func methodAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var m methodW
+
+	if res := decodeJSON(r, &m); !res.Ok {
+		return res
+	}
+
+	switch {
+	case m.MethodID == "":
+		return weft.BadRequest("missing methodID")
+	case m.Name == "":
+		return weft.BadRequest("missing name")
+	case m.Description == "":
+		return weft.BadRequest("missing description")
+	case m.Reference == "":
+		return weft.BadRequest("missing reference")
+	}
+
+	if _, err := dbW.Exec(`INSERT INTO fits.method(methodID, name, description, reference) VALUES ($1, $2, $3, $4)`,
+		m.MethodID, m.Name, m.Description, m.Reference); err != nil {
+		return insertError(err, "methodID "+m.MethodID+" already exists")
+	}
+
+	return writeJSON(m, h, b)
+}
+
+/*
+methodDelete deletes a method and any links to types.  Methods
+that are used by observations or previous versions of observations can't be deleted.
+*/
+func methodDelete(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"methodID"}, []string{}); !res.Ok {
+		return res
+	}
+
+	methodID := r.URL.Query().Get("methodID")
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	var methodPK int
+	var used bool
+
+	if err = tx.QueryRow(`SELECT methodpk,
+		EXISTS(SELECT 1 FROM fits.observation WHERE observation.methodpk = method.methodpk) OR
+		EXISTS(SELECT 1 FROM fits.observation_revision WHERE observation_revision.methodpk = method.methodpk)
+		FROM fits.method WHERE methodid = $1 FOR UPDATE`, methodID).Scan(&methodPK, &used); err != nil {
+		return notFoundOr(err)
+	}
+
+	if used {
+		return &weft.Result{Ok: false, Code: http.StatusConflict, Msg: "methodID " + methodID + " has observations or observation revisions"}
+	}
+
+	if _, err = tx.Exec(`DELETE FROM fits.type_method WHERE methodpk = $1`, methodPK); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if _, err = tx.Exec(`DELETE FROM fits.method WHERE methodpk = $1`, methodPK); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return &weft.StatusOK
+}
+
+// typeMethodLink makes methodID a valid method for typeID.
+func typeMethodLink(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"typeID", "methodID"}, []string{}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	res, err := dbW.Exec(`INSERT INTO fits.type_method(typePK, methodPK)
+		SELECT typepk, methodpk FROM fits.type, fits.method WHERE typeid = $1 AND methodid = $2`, v.Get("typeID"), v.Get("methodID"))
+	if err != nil {
+		return insertError(err, "methodID "+v.Get("methodID")+" is already linked to typeID "+v.Get("typeID"))
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if n != 1 {
+		return &weft.NotFound
+	}
+
+	return &weft.StatusOK
+}
+
+// typeMethodUnlink removes methodID as a valid method for typeID.  Links
+// that are used by observations or observation revisions can't be removed.
+func typeMethodUnlink(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"typeID", "methodID"}, []string{}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	var typePK, methodPK int
+	var used bool
+
+	if err = tx.QueryRow(`SELECT typepk, methodpk,
+		EXISTS(SELECT 1 FROM fits.observation o WHERE o.typepk = tm.typepk AND o.methodpk = tm.methodpk) OR
+		EXISTS(SELECT 1 FROM fits.observation_revision o WHERE o.typepk = tm.typepk AND o.methodpk = tm.methodpk)
+		FROM fits.type_method tm join fits.type using (typepk) join fits.method using (methodpk)
+		WHERE typeid = $1 AND methodid = $2 FOR UPDATE OF tm`, v.Get("typeID"), v.Get("methodID")).Scan(&typePK, &methodPK, &used); err != nil {
+		return notFoundOr(err)
+	}
+
+	if used {
+		return &weft.Result{Ok: false, Code: http.StatusConflict,
+			Msg: "methodID " + v.Get("methodID") + " has observations or observation revisions for typeID " + v.Get("typeID")}
+	}
+
+	if _, err = tx.Exec(`DELETE FROM fits.type_method WHERE typepk = $1 AND methodpk = $2`, typePK, methodPK); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return &weft.StatusOK
+}
+
+// insertError returns a conflict with msg for unique violations and service unavailable for other errors.
+func insertError(err error, msg string) *weft.Result {
+	if e, ok := err.(*pq.Error); ok && e.Code == "23505" {
+		return &weft.Result{Ok: false, Code: http.StatusConflict, Msg: msg}
+	}
+
+	return weft.ServiceUnavailableError(err)
+}
+
+// notFoundOr returns not found for sql.ErrNoRows and service unavailable for other errors.
+func notFoundOr(err error) *weft.Result {
+	if err == sql.ErrNoRows {
+		return &weft.NotFound
+	}
+
+	return weft.ServiceUnavailableError(err)
+}
+
+// writeJSON writes v to b as JSON.
+func writeJSON(v interface{}, h http.Header, b *bytes.Buffer) *weft.Result {
+	by, err := json.Marshal(v)
+	if err != nil {
+		return weft.InternalServerError(err)
+	}
+
+	h.Set("Content-Type", v1JSON)
+	b.Write(by)
+
+	return &weft.StatusOK
+}
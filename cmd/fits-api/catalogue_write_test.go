@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCatalogueWrite(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove the catalogue entries from any earlier test run.
+	for _, q := range []string{
+		`DELETE FROM fits.type_method WHERE typepk IN (SELECT typepk FROM fits.type WHERE typeid = 't3')
+			OR methodpk IN (SELECT methodpk FROM fits.method WHERE methodid = 'm4')`,
+		`DELETE FROM fits.type WHERE typeid = 't3'`,
+		`DELETE FROM fits.method WHERE methodid = 'm4'`,
+		`DELETE FROM fits.unit WHERE symbol = 'ppm'`,
+	} {
+		if _, err := dbW.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := []struct {
+		id, method, url, body string
+		status                int
+	}{
+		{id: "unit", method: "POST", url: "/unit", status: http.StatusOK, body: `{"symbol":"ppm","name":"parts per million"}`},
+		{id: "unit exists", method: "POST", url: "/unit", status: http.StatusConflict, body: `{"symbol":"ppm","name":"parts per million"}`},
+		{id: "type", method: "POST", url: "/type", status: http.StatusOK,
+			body: `{"typeID":"t3","name":"Type 3","description":"Test data type 3","unit":"ppm"}`},
+		{id: "type exists", method: "POST", url: "/type", status: http.StatusConflict,
+			body: `{"typeID":"t3","name":"Type 3","description":"Test data type 3","unit":"ppm"}`},
+		{id: "type unknown unit", method: "POST", url: "/type", status: http.StatusBadRequest,
+			body: `{"typeID":"t4","name":"Type 4","description":"Test data type 4","unit":"bob"}`},
+		{id: "method", method: "POST", url: "/method", status: http.StatusOK,
+			body: `{"methodID":"m4","name":"Method 4","description":"Test data method 4","reference":"a link"}`},
+		{id: "method missing reference", method: "POST", url: "/method", status: http.StatusBadRequest,
+			body: `{"methodID":"m5","name":"Method 5","description":"Test data method 5"}`},
+		{id: "link", method: "PUT", url: "/type_method?typeID=t3&methodID=m4", status: http.StatusOK},
+		{id: "unlink", method: "DELETE", url: "/type_method?typeID=t3&methodID=m4", status: http.StatusOK},
+		{id: "link again", method: "PUT", url: "/type_method?typeID=t3&methodID=m4", status: http.StatusOK},
+		{id: "delete method", method: "DELETE", url: "/method?methodID=m4", status: http.StatusOK},
+	}
+
+	for _, v := range in {
+		req, err := http.NewRequest(v.method, testServer.URL+v.url, strings.NewReader(v.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.body != "" {
+			req.Header.Set("Content-Type", v1JSON)
+		}
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != v.status {
+			t.Errorf("%s: expected status %d got %d", v.id, v.status, res.StatusCode)
+		}
+	}
+}
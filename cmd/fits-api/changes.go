@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	changesLimit    = 1000
+	changesMaxLimit = 10000
+)
+
+// obsChange is an observation that was inserted or updated, including changes to the qc flag.
+// The fields match obsRow, apart from qc, so changes can be added to another FITS.
+type obsChange struct {
+	NetworkID string    `json:"networkID"`
+	SiteID    string    `json:"siteID"`
+	TypeID    string    `json:"typeID"`
+	MethodID  string    `json:"methodID"`
+	SampleID  string    `json:"sampleID"`
+	SystemID  string    `json:"systemID"`
+	Time      time.Time `json:"time"`
+	Value     float64   `json:"value"`
+	Error     float64   `json:"error"`
+	QC        string    `json:"qc"`
+	Inserted  time.Time `json:"inserted"`
+	Updated   time.Time `json:"updated"`
+}
+
+type changes struct {
+	Observations []obsChange `json:"observations"`
+	Next         string      `json:"next,omitempty"` // the cursor for the next page.  Empty if there are no more changes.
+}
+
+/*
+observationChanges returns observations inserted or updated since a time, in the order of the
+transactions that changed them.  Results are paged.  Pass the next value from the response as the
+cursor query parameter (with the same since) to get the next page.
+
+Only changes made by transactions older than the oldest transaction still running in the DB are
+returned (txid_snapshot_xmin).  Transactions can commit in a different order to the change numbers
+they use, so this makes sure an earlier change can't be committed behind a cursor that has already been
+handed out.  The limit is that while any transaction that writes to the DB stays open no newer changes
+are returned, even when they have been committed, so a long running load will delay the feed until it finishes.
+*/
+func observationChanges(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"since"}, []string{"cursor", "limit"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	since, err := time.Parse(time.RFC3339, v.Get("since"))
+	if err != nil {
+		return weft.BadRequest("Invalid since query param.")
+	}
+
+	var txid, cursor int64
+
+	if v.Get("cursor") != "" {
+		if txid, cursor, err = parseCursor(v.Get("cursor")); err != nil {
+			return weft.BadRequest("Invalid cursor query param.")
+		}
+	}
+
+	limit := changesLimit
+
+	if v.Get("limit") != "" {
+		limit, err = strconv.Atoi(v.Get("limit"))
+		if err != nil || limit <= 0 || limit > changesMaxLimit {
+			return weft.BadRequest("Invalid limit query param.")
+		}
+	}
+
+	// fetch one more than limit to find if there is another page.
+	rows, err := db.Query(`SELECT networkid, siteid, typeid, methodid, sampleid, systemid,
+		time, value, error, qc, inserted, updated, txid, change
+		FROM fits.observation
+		join fits.site using (sitepk) join fits.network using (networkpk)
+		join fits.type using (typepk) join fits.method using (methodpk)
+		join fits.sample using (samplepk) join fits.system using (systempk)
+		WHERE updated >= $1 AND (txid, change) > ($2, $3)
+		AND txid < txid_snapshot_xmin(txid_current_snapshot())
+		ORDER BY txid ASC, change ASC
+		LIMIT $4`, since, txid, cursor, limit+1)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer rows.Close()
+
+	c := changes{Observations: []obsChange{}}
+
+	var lastTxid, last int64
+
+	for rows.Next() {
+		var o obsChange
+		var t, change int64
+
+		if err = rows.Scan(&o.NetworkID, &o.SiteID, &o.TypeID, &o.MethodID, &o.SampleID, &o.SystemID,
+			&o.Time, &o.Value, &o.Error, &o.QC, &o.Inserted, &o.Updated, &t, &change); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		if len(c.Observations) == limit {
+			c.Next = formatCursor(lastTxid, last)
+			break
+		}
+
+		c.Observations = append(c.Observations, o)
+		lastTxid, last = t, change
+	}
+	if err = rows.Err(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeJSON(c, h, b)
+}
+
+// formatCursor returns the cursor for the change after txid and change.
+func formatCursor(txid, change int64) string {
+	return strconv.FormatInt(txid, 10) + "-" + strconv.FormatInt(change, 10)
+}
+
+// parseCursor returns the txid and change from a cursor made by formatCursor.
+func parseCursor(c string) (txid, change int64, err error) {
+	p := strings.Split(c, "-")
+	if len(p) != 2 {
+		err = fmt.Errorf("invalid cursor %s", c)
+		return
+	}
+
+	if txid, err = strconv.ParseInt(p[0], 10, 64); err != nil {
+		return
+	}
+
+	if change, err = strconv.ParseInt(p[1], 10, 64); err != nil {
+		return
+	}
+
+	if txid < 0 || change < 0 {
+		err = fmt.Errorf("invalid cursor %s", c)
+	}
+
+	return
+}
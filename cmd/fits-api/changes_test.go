@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservationChanges(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove observations from any earlier test run so they are inserted again.
+	if _, err := dbW.Exec(`DELETE FROM fits.observation WHERE time >= '2003-01-01T00:00:00Z' AND time < '2004-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	var since time.Time
+	if err := db.QueryRow(`SELECT now()`).Scan(&since); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `[{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2003-01-08T12:00:00Z","value":1.1,"error":0.1},
+		{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2003-01-09T12:00:00Z","value":1.2,"error":0.1}]`
+
+	// the second add doesn't change any values so shouldn't show as a change.
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("POST", testServer.URL+"/observation", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", v1JSON)
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200 got %d", res.StatusCode)
+		}
+	}
+
+	var got []obsChange
+	var cursor string
+
+	for i := 0; i < 10; i++ {
+		u := testServer.URL + "/observation/changes?limit=1&since=" + since.UTC().Format(time.RFC3339)
+		if cursor != "" {
+			u += "&cursor=" + cursor
+		}
+
+		res, err := http.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c changes
+		err = json.NewDecoder(res.Body).Decode(&c)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// other tests may have made changes at about the same time.
+		for _, o := range c.Observations {
+			if o.Time.Year() == 2003 && o.Time.Month() == time.January {
+				got = append(got, o)
+			}
+		}
+
+		if c.Next == "" {
+			break
+		}
+		cursor = c.Next
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 changes got %d", len(got))
+	}
+
+	if got[0].Value != 1.1 || got[1].Value != 1.2 {
+		t.Errorf("unexpected changes %+v", got)
+	}
+}
+
+// TestObservationChangesOverlapping checks that a change committed while an earlier
+// transaction is still open isn't returned before the change from the earlier transaction.
+func TestObservationChangesOverlapping(t *testing.T) {
+	setup()
+	defer teardown()
+
+	if _, err := dbW.Exec(`DELETE FROM fits.observation WHERE time >= '2003-02-01T00:00:00Z' AND time < '2003-03-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	var since time.Time
+	if err := db.QueryRow(`SELECT now()`).Scan(&since); err != nil {
+		t.Fatal(err)
+	}
+
+	add := `SELECT fits.add_observation('TN1', 'TEST3', 't2', 'm1', 'none', 'none', $1, $2, 0.1)`
+
+	// the first write uses the lower change number but commits last.
+	tx, err := dbW.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(add, "2003-02-01T12:00:00Z", 2.1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = dbW.Exec(add, "2003-02-02T12:00:00Z", 2.2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := changesFeb2003(t, since); len(got) != 0 {
+		t.Errorf("expected no changes while the first write is open got %+v", got)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := changesFeb2003(t, since)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 changes got %d", len(got))
+	}
+
+	if got[0].Value != 2.1 || got[1].Value != 2.2 {
+		t.Errorf("unexpected changes %+v", got)
+	}
+}
+
+// changesFeb2003 pages through the changes since with limit=1 and returns those for February 2003.
+func changesFeb2003(t *testing.T, since time.Time) []obsChange {
+	var got []obsChange
+	var cursor string
+
+	for i := 0; i < 100; i++ {
+		u := testServer.URL + "/observation/changes?limit=1&since=" + since.UTC().Format(time.RFC3339)
+		if cursor != "" {
+			u += "&cursor=" + cursor
+		}
+
+		res, err := http.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var c changes
+		err = json.NewDecoder(res.Body).Decode(&c)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, o := range c.Observations {
+			if o.Time.Year() == 2003 && o.Time.Month() == time.February {
+				got = append(got, o)
+			}
+		}
+
+		if c.Next == "" {
+			break
+		}
+		cursor = c.Next
+	}
+
+	return got
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"github.com/GeoNet/weft"
+	"net/url"
+)
+
+// converted converts values and errors to another unit.  The first %s is any extra columns to select,
+// then the factor, offset, and factor again, and last the source of the observations.
+const converted = `(SELECT sitepk, typepk, methodpk, samplepk, time, qc%s,
+		(value * %s + %s)::float8 AS value, (error * abs(%s))::float8 AS error
+		FROM %s) AS observation`
+
+/*
+unitQ is the unit for observation values.  factor and offset convert values from the unit for the type
+and are from the DB as SQL numeric literals.  They are empty if no conversion is needed.
+*/
+type unitQ struct {
+	symbol         string
+	factor, offset string
+}
+
+// on is true if values need converting.
+func (u unitQ) on() bool {
+	return u.factor != ""
+}
+
+/*
+getUnit returns the unitQ for the unit query parameter.  unit is the symbol for the
+unit of the type.  If the unit query parameter is empty or the same as unit then no conversion is needed.
+*/
+func getUnit(v url.Values, typeID, unit string) (unitQ, *weft.Result) {
+	u := unitQ{symbol: v.Get("unit")}
+
+	if u.symbol == "" || u.symbol == unit {
+		return unitQ{symbol: unit}, &weft.StatusOK
+	}
+
+	err := db.QueryRow(`SELECT factor::text, value_offset::text FROM fits.unit_conversion
+		WHERE fromPK = (SELECT unitPK FROM fits.type WHERE typeID = $1)
+		AND toPK = (SELECT unitPK FROM fits.unit WHERE symbol = $2)`, typeID, u.symbol).Scan(&u.factor, &u.offset)
+	switch err {
+	case nil:
+		return u, &weft.StatusOK
+	case sql.ErrNoRows:
+		return unitQ{}, weft.BadRequest("no conversion from " + unit + " to " + u.symbol + " for unit query param.")
+	default:
+		return unitQ{}, weft.ServiceUnavailableError(err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnitConversion(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for _, c := range []struct {
+		id, path string
+		expected []float64
+	}{
+		{"m to mm", "/observation?networkID=TN1&siteID=TEST1&typeID=t1&methodID=m1&start=2000-01-06T00:00:00Z&end=2000-01-07T00:00:00Z&unit=mm", []float64{1520}},
+		{"K to °C", "/observation?networkID=TN1&siteID=TEST2&typeID=t2&unit=%C2%B0C", []float64{-264.03}},
+		{"same unit", "/observation?networkID=TN1&siteID=TEST2&typeID=t2&unit=K", []float64{9.12}},
+	} {
+		var values []value
+
+		getJSON(t, c.path, &values)
+
+		if len(values) != len(c.expected) {
+			t.Errorf("%s: expected %d values got %d", c.id, len(c.expected), len(values))
+			continue
+		}
+
+		for i := range values {
+			if math.Abs(values[i].V-c.expected[i]) > 1e-9 {
+				t.Errorf("%s: expected %f got %f", c.id, c.expected[i], values[i].V)
+			}
+		}
+	}
+
+	var s obstats
+
+	getJSON(t, "/observation/stats?networkID=TN1&siteID=TEST2&typeID=t2&unit=%C2%B0C", &s)
+
+	if s.Unit != "°C" {
+		t.Errorf("expected unit °C got %s", s.Unit)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"github.com/GeoNet/fits/internal/ts"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"time"
+)
+
+/*
+epoch is an equipment change at a site e.g., an antenna swap or sensor replacement.
+Offsets are the steps in the values for each type caused by the change.  They are
+subtracted from observations at or after the epoch for corrected values.
+*/
+type epoch struct {
+	NetworkID   string        `json:"networkID"`
+	SiteID      string        `json:"siteID"`
+	Time        time.Time     `json:"time"`
+	Description string        `json:"description"`
+	Offsets     []epochOffset `json:"offsets"`
+}
+
+type epochOffset struct {
+	TypeID string   `json:"typeID"`
+	Offset *float64 `json:"offset"`
+}
+
+// siteEpochs returns the equipment epochs for a site in time order.
+func siteEpochs(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID"}, []string{}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	if res := validSite(v.Get("networkID"), v.Get("siteID")); !res.Ok {
+		return res
+	}
+
+	return writeEpochs(v.Get("networkID"), v.Get("siteID"), h, b)
+}
+
+/*
+epochAdd reads an epoch as JSON from the request body and adds it to the DB.  If there is already an epoch
+for the site at the same time then the description and offsets are replaced.
+The epochs for the site are written to b.
+*/
+func epochAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var e epoch
+
+	if res := decodeJSON(r, &e); !res.Ok {
+		return res
+	}
+
+	if res := e.valid(); !res.Ok {
+		return res
+	}
+
+	if res := validSite(e.NetworkID, e.SiteID); !res.Ok {
+		if res.Code == http.StatusNotFound {
+			return weft.BadRequest("unknown site " + e.NetworkID + "." + e.SiteID)
+		}
+		return res
+	}
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	var epochPK int64
+
+	if err = tx.QueryRow(`INSERT INTO fits.site_epoch (sitepk, time, description)
+		SELECT sitepk, $3, $4 FROM fits.site join fits.network using (networkpk) WHERE siteid = $2 AND networkid = $1
+		ON CONFLICT (sitepk, time) DO UPDATE SET description = EXCLUDED.description
+		RETURNING epochpk`, e.NetworkID, e.SiteID, e.Time, e.Description).Scan(&epochPK); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if _, err = tx.Exec(`DELETE FROM fits.epoch_offset WHERE epochpk = $1`, epochPK); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	for _, o := range e.Offsets {
+		res, err := tx.Exec(`INSERT INTO fits.epoch_offset (epochpk, typepk, value_offset)
+			SELECT $1, typepk, $3 FROM fits.type WHERE typeid = $2`, epochPK, o.TypeID, *o.Offset)
+		if err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		if n != 1 {
+			return weft.BadRequest("unknown typeID " + o.TypeID)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeEpochs(e.NetworkID, e.SiteID, h, b)
+}
+
+/*
+epochDelete deletes the epoch and its offsets for a site at the time query parameter (RFC3339).
+The remaining epochs for the site are written to b.
+*/
+func epochDelete(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID", "time"}, []string{}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	t, err := time.Parse(time.RFC3339Nano, v.Get("time"))
+	if err != nil {
+		return weft.BadRequest("Invalid time query param.")
+	}
+
+	res, err := dbW.Exec(`DELETE FROM fits.site_epoch WHERE time = $3 AND sitepk = (
+		SELECT sitepk FROM fits.site join fits.network using (networkpk) WHERE siteid = $2 AND networkid = $1
+		)`, v.Get("networkID"), v.Get("siteID"), t)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if n != 1 {
+		return &weft.NotFound
+	}
+
+	return writeEpochs(v.Get("networkID"), v.Get("siteID"), h, b)
+}
+
+// valid checks all fields are set and that each type has only one offset.
+func (e epoch) valid() *weft.Result {
+	switch {
+	case e.NetworkID == "":
+		return weft.BadRequest("missing networkID")
+	case e.SiteID == "":
+		return weft.BadRequest("missing siteID")
+	case e.Time.IsZero():
+		return weft.BadRequest("missing time")
+	case e.Description == "":
+		return weft.BadRequest("missing description")
+	}
+
+	types := make(map[string]bool)
+
+	for _, o := range e.Offsets {
+		switch {
+		case o.TypeID == "":
+			return weft.BadRequest("missing typeID for offset")
+		case o.Offset == nil:
+			return weft.BadRequest("missing offset for typeID " + o.TypeID)
+		case !finite(*o.Offset):
+			return weft.BadRequest("invalid offset for typeID " + o.TypeID)
+		case types[o.TypeID]:
+			return weft.BadRequest("more than one offset for typeID " + o.TypeID)
+		}
+		types[o.TypeID] = true
+	}
+
+	return &weft.StatusOK
+}
+
+// writeEpochs writes the epochs for the site to b as JSON.
+func writeEpochs(networkID, siteID string, h http.Header, b *bytes.Buffer) *weft.Result {
+	epochs, err := loadEpochs(networkID, siteID, time.Time{}, time.Time{})
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeJSON(epochs, h, b)
+}
+
+/*
+loadEpochs returns the epochs for the site in time order.  If start is non zero
+only epochs at or after start are returned.  If end is non zero only epochs at or before end.
+*/
+func loadEpochs(networkID, siteID string, start, end time.Time) ([]epoch, error) {
+	rows, err := db.Query(`SELECT epochpk, time, site_epoch.description, typeid, value_offset
+		FROM fits.site_epoch join fits.site using (sitepk) join fits.network using (networkpk)
+		left join fits.epoch_offset using (epochpk) left join fits.type using (typepk)
+		WHERE siteid = $2 AND networkid = $1
+		AND ($3::timestamptz IS NULL OR time >= $3)
+		AND ($4::timestamptz IS NULL OR time <= $4)
+		ORDER BY time ASC, typeid ASC`, networkID, siteID, nullTime(start), nullTime(end))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	epochs := []epoch{}
+	var last int64
+
+	for rows.Next() {
+		var epochPK int64
+		var t time.Time
+		var description string
+		var typeID *string
+		var offset *float64
+
+		if err = rows.Scan(&epochPK, &t, &description, &typeID, &offset); err != nil {
+			return nil, err
+		}
+
+		if len(epochs) == 0 || epochPK != last {
+			epochs = append(epochs, epoch{
+				NetworkID:   networkID,
+				SiteID:      siteID,
+				Time:        t.UTC(),
+				Description: description,
+				Offsets:     []epochOffset{},
+			})
+			last = epochPK
+		}
+
+		if typeID != nil {
+			e := &epochs[len(epochs)-1]
+			e.Offsets = append(e.Offsets, epochOffset{TypeID: *typeID, Offset: offset})
+		}
+	}
+
+	return epochs, rows.Err()
+}
+
+// nullTime returns nil for a zero t so it is NULL in a query.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
+
+/*
+addEpochEvents adds the equipment epochs for the site as events on the plot.
+start and end are as for loadEpochs.
+*/
+func (plt *plt) addEpochEvents(s siteQ, start, end time.Time) error {
+	epochs, err := loadEpochs(s.networkID, s.siteID, start, end)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range epochs {
+		plt.AddEvent(ts.Event{DateTime: e.Time, Label: e.Time.Format(time.RFC3339) + " " + e.Description})
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSiteEpoch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove observations and epochs from any earlier test run.
+	if _, err := dbW.Exec(`DELETE FROM fits.observation WHERE time >= '2006-01-01T00:00:00Z' AND time < '2007-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbW.Exec(`DELETE FROM fits.site_epoch WHERE time >= '2006-01-01T00:00:00Z' AND time < '2007-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", testServer.URL+"/observation", strings.NewReader(
+		`[{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2006-01-08T12:00:00Z","value":1.0,"error":0.1},
+		{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2006-02-08T12:00:00Z","value":3.0,"error":0.1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", v1JSON)
+	req.SetBasicAuth("test", "test")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 got %d", res.StatusCode)
+	}
+
+	// adding the epoch a second time replaces the offsets.
+	for _, offset := range []string{"1.5", "2.0"} {
+		req, err = http.NewRequest("POST", testServer.URL+"/site/epoch", strings.NewReader(
+			`{"networkID":"TN1","siteID":"TEST3","time":"2006-02-01T00:00:00Z","description":"Antenna replaced","offsets":[{"typeID":"t2","offset":`+offset+`}]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", v1JSON)
+		req.SetBasicAuth("test", "test")
+
+		res, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var e []epoch
+		err = json.NewDecoder(res.Body).Decode(&e)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200 got %d", res.StatusCode)
+		}
+
+		if len(e) != 1 || len(e[0].Offsets) != 1 || e[0].Offsets[0].Offset == nil {
+			t.Fatalf("unexpected epochs %+v", e)
+		}
+	}
+
+	var values []value
+
+	getJSON(t, "/observation?networkID=TN1&siteID=TEST3&typeID=t2&start=2006-01-01T00:00:00Z&end=2007-01-01T00:00:00Z&corrected=true", &values)
+
+	if len(values) != 2 || values[0].V != 1.0 || values[1].V != 1.0 {
+		t.Errorf("expected corrected values 1.0 and 1.0 got %+v", values)
+	}
+
+	getJSON(t, "/observation?networkID=TN1&siteID=TEST3&typeID=t2&start=2006-01-01T00:00:00Z&end=2007-01-01T00:00:00Z", &values)
+
+	if len(values) != 2 || values[0].V != 1.0 || values[1].V != 3.0 {
+		t.Errorf("expected values 1.0 and 3.0 got %+v", values)
+	}
+
+	req, err = http.NewRequest("DELETE", testServer.URL+"/site/epoch?networkID=TN1&siteID=TEST3&time=2006-02-01T00:00:00Z", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("test", "test")
+
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 got %d", res.StatusCode)
+	}
+
+	getJSON(t, "/observation?networkID=TN1&siteID=TEST3&typeID=t2&start=2006-01-01T00:00:00Z&end=2007-01-01T00:00:00Z&corrected=true", &values)
+
+	if len(values) != 2 || values[1].V != 3.0 {
+		t.Errorf("expected no correction after deleting the epoch got %+v", values)
+	}
+}
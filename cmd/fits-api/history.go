@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+version is one version of an observation.  Replaced is null for the
+current version.
+*/
+type version struct {
+	MethodID    string     `json:"methodID"`
+	SampleID    string     `json:"sampleID"`
+	SystemID    string     `json:"systemID"`
+	Time        time.Time  `json:"time"`
+	Value       float64    `json:"value"`
+	Error       float64    `json:"error"`
+	QC          string     `json:"qc"`
+	Published   time.Time  `json:"published"`
+	PublishedBy string     `json:"publishedBy"`
+	Replaced    *time.Time `json:"replaced"`
+	ReplacedBy  string     `json:"replacedBy,omitempty"`
+}
+
+/*
+observationHistory returns the current and previous versions of the observations for a
+site and type at a time, ordered by method, sample, and when each version was published.
+*/
+func observationHistory(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID", "typeID", "time"}, []string{"methodID"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	var s siteQ
+	var t typeQ
+	var res *weft.Result
+
+	if t, res = getType(v); !res.Ok {
+		return res
+	}
+
+	if s, res = getSite(v); !res.Ok {
+		return res
+	}
+
+	if v.Get("methodID") != "" {
+		if res = validTypeMethod(t.typeID, v.Get("methodID")); !res.Ok {
+			return res
+		}
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, v.Get("time"))
+	if err != nil {
+		return weft.BadRequest("Invalid time query param.")
+	}
+
+	q := obsQ{
+		networkID: s.networkID,
+		siteID:    s.siteID,
+		typeID:    t.typeID,
+		methodID:  v.Get("methodID"),
+	}
+
+	where, args := q.where()
+	args = append(args, at)
+
+	rows, err := db.Query(`SELECT methodid, sampleid, systemid, time, value, error, qc, published, published_by, replaced, replaced_by FROM `+revisions+`
+		join fits.method using (methodpk) join fits.sample using (samplepk) join fits.system using (systempk)
+		`+where+`
+		AND time = $`+strconv.Itoa(len(args))+`
+		ORDER BY methodid, systemid, sampleid, published ASC`, args...)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer rows.Close()
+
+	var versions []version
+
+	for rows.Next() {
+		var o version
+
+		if err = rows.Scan(&o.MethodID, &o.SampleID, &o.SystemID, &o.Time, &o.Value, &o.Error, &o.QC,
+			&o.Published, &o.PublishedBy, &o.Replaced, &o.ReplacedBy); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		versions = append(versions, o)
+	}
+	if err = rows.Err(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if len(versions) == 0 {
+		return &weft.NotFound
+	}
+
+	return writeJSON(versions, h, b)
+}
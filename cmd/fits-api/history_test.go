@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObservationHistory(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove observations from any earlier test run.
+	if _, err := dbW.Exec(`DELETE FROM fits.observation WHERE time >= '2004-01-01T00:00:00Z' AND time < '2005-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbW.Exec(`DELETE FROM fits.observation_revision WHERE time >= '2004-01-01T00:00:00Z' AND time < '2005-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{"1.1", "1.2"} {
+		req, err := http.NewRequest("POST", testServer.URL+"/observation", strings.NewReader(
+			`[{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2004-01-08T12:00:00Z","value":`+v+`,"error":0.1}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", v1JSON)
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200 got %d", res.StatusCode)
+		}
+	}
+
+	var versions []version
+
+	getJSON(t, "/observation/history?networkID=TN1&siteID=TEST3&typeID=t2&time=2004-01-08T12:00:00Z", &versions)
+
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions got %d", len(versions))
+	}
+
+	if versions[0].Value != 1.1 || versions[1].Value != 1.2 {
+		t.Errorf("unexpected versions %+v", versions)
+	}
+
+	if versions[0].Replaced == nil || !versions[0].Replaced.Equal(versions[1].Published) || versions[0].ReplacedBy != "test" {
+		t.Errorf("expected the first version to be replaced by the second %+v", versions)
+	}
+
+	if versions[1].Replaced != nil || versions[1].PublishedBy != "test" {
+		t.Errorf("expected the second version to be current %+v", versions)
+	}
+
+	// the observations as they were when each version was published.
+	for _, v := range versions {
+		var values []value
+
+		getJSON(t, "/observation?networkID=TN1&siteID=TEST3&typeID=t2&start=2004-01-01T00:00:00Z&end=2005-01-01T00:00:00Z&asOf="+
+			v.Published.UTC().Format(time.RFC3339Nano), &values)
+
+		if len(values) != 1 || values[0].V != v.Value {
+			t.Errorf("asOf %s expected value %f got %+v", v.Published, v.Value, values)
+		}
+	}
+}
+
+// getJSON gets the JSON from the test server for path and decodes it into v.
+func getJSON(t *testing.T, path string, v interface{}) {
+	req, err := http.NewRequest("GET", testServer.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", v1JSON)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s expected status 200 got %d", path, res.StatusCode)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"github.com/GeoNet/fits/internal/ts"
+	"github.com/GeoNet/weft"
+	"net/http"
+)
+
+// plotter is implemented by the plots and sparks in ts.
+type plotter interface {
+	Draw(p ts.Plot, l ts.Layout, b *bytes.Buffer) error
+	Image(p ts.Plot, l ts.Layout, format string, b *bytes.Buffer) error
+}
+
+/*
+writePlot draws p with d to b in format and sets the Content-Type for it.
+format is from getFormat.  p is drawn as SVG for an empty format or svg.
+*/
+func writePlot(d plotter, p ts.Plot, l ts.Layout, format string, h http.Header, b *bytes.Buffer) *weft.Result {
+	var err error
+
+	switch format {
+	case ts.PNG:
+		h.Set("Content-Type", png)
+		err = d.Image(p, l, format, b)
+	case ts.PDF:
+		h.Set("Content-Type", pdf)
+		err = d.Image(p, l, format, b)
+	default:
+		err = d.Draw(p, l, b)
+	}
+
+	switch err {
+	case nil:
+		return &weft.StatusOK
+	case ts.ErrLayout:
+		return weft.BadRequest(err.Error())
+	default:
+		return weft.ServiceUnavailableError(err)
+	}
+}
+
+/*
+writeMap converts the SVG map in b to format and sets the Content-Type for it.
+format is from getFormat.  b is left as SVG for an empty format or svg.
+*/
+func writeMap(format string, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch format {
+	case ts.PNG:
+		h.Set("Content-Type", png)
+	case ts.PDF:
+		h.Set("Content-Type", pdf)
+	default:
+		return &weft.StatusOK
+	}
+
+	var o bytes.Buffer
+
+	if err := ts.RenderMap(b.Bytes(), format, &o); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.Reset()
+	b.Write(o.Bytes())
+
+	return &weft.StatusOK
+}
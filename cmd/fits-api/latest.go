@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"strings"
+)
+
+const latestGeoJSON = `SELECT row_to_json(fc)
+	FROM ( SELECT 'FeatureCollection' as type, COALESCE(array_to_json(array_agg(f)), '[]') as features
+	FROM (SELECT 'Feature' as type,
+	ST_AsGeoJSON(s.location)::json as geometry,
+	row_to_json((SELECT l FROM
+		(
+			SELECT ` + siteProperties + `,
+			to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') AS "time",
+			value,
+			error,
+			extract(epoch from now() - time) AS "ageSeconds"
+		) as l
+	)) as properties FROM (fits.site join fits.network using (networkpk) join latest using (sitepk)) as s `
+
+/*
+observationLatest returns the latest observation for typeID at each site as GeoJSON.
+The observations can be restricted by methodID and sites within a polygon.
+*/
+func observationLatest(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"typeID"}, []string{"methodID", "within"}); !res.Ok {
+		return res
+	}
+
+	h.Set("Content-Type", v1GeoJSON)
+
+	v := r.URL.Query()
+
+	typeID := v.Get("typeID")
+	var res *weft.Result
+
+	if res = validType(typeID); !res.Ok {
+		return res
+	}
+
+	args := []interface{}{typeID}
+
+	latest := `WITH latest AS (SELECT DISTINCT ON (sitepk) sitepk, time, value, error FROM fits.observation
+		WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1)`
+
+	if v.Get("methodID") != "" {
+		if res = validTypeMethod(typeID, v.Get("methodID")); !res.Ok {
+			return res
+		}
+
+		args = append(args, v.Get("methodID"))
+		latest += fmt.Sprintf(` AND methodpk = (SELECT methodpk FROM fits.method WHERE methodid = $%d)`, len(args))
+	}
+
+	latest += ` ORDER BY sitepk, time DESC) `
+
+	var where string
+
+	if v.Get("within") != "" {
+		within := strings.Replace(v.Get("within"), "+", "", -1)
+		if res = validPoly(within); !res.Ok {
+			return res
+		}
+
+		args = append(args, within)
+		where = fmt.Sprintf(` WHERE ST_Within(ST_Shift_Longitude(location::geometry), ST_Shift_Longitude(ST_GeomFromText($%d, 4326)))`, len(args))
+	}
+
+	var d string
+
+	if err := db.QueryRow(latest+latestGeoJSON+where+` ORDER BY networkid, siteid`+fc, args...).Scan(&d); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.WriteString(d)
+
+	return &weft.StatusOK
+}
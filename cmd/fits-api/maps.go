@@ -14,13 +14,18 @@ type st struct {
 }
 
 func siteMap(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{}, []string{"networkID", "siteID", "sites", "width", "bbox", "insetBbox"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{}, []string{"networkID", "siteID", "sites", "width", "bbox", "insetBbox", "format"}); !res.Ok {
 		return res
 	}
 	h.Set("Content-Type", "image/svg+xml")
 
 	v := r.URL.Query()
 
+	format, res := getFormat(v)
+	if !res.Ok {
+		return res
+	}
+
 	bbox := v.Get("bbox")
 
 	var insetBbox string
@@ -104,17 +109,22 @@ func siteMap(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	byt := by.Bytes()
 	b.Write(byt)
 
-	return &weft.StatusOK
+	return writeMap(format, h, b)
 }
 
 func siteTypeMap(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{}, []string{"typeID", "methodID", "within", "width", "bbox", "insetBbox"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{}, []string{"typeID", "methodID", "within", "width", "bbox", "insetBbox", "format"}); !res.Ok {
 		return res
 	}
 	h.Set("Content-Type", "image/svg+xml")
 
 	v := r.URL.Query()
 
+	format, res := getFormat(v)
+	if !res.Ok {
+		return res
+	}
+
 	bbox := v.Get("bbox")
 
 	err := map180.ValidBbox(bbox)
@@ -189,5 +199,5 @@ func siteTypeMap(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	byt := by.Bytes()
 	b.Write(byt)
 
-	return &weft.StatusOK
+	return writeMap(format, h, b)
 }
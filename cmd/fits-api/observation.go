@@ -5,8 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"github.com/GeoNet/weft"
-	"log"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,12 +20,10 @@ func init() {
 }
 
 func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"siteID", "networkID", "typeID"}, []string{"days", "methodID"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"siteID", "networkID", "typeID"}, []string{"days", "start", "end", "methodID", "systemID", "sampleID", "showSample", "showQC", "interval", "aggregate", "asOf", "qc", "corrected", "unit"}); !res.Ok {
 		return res
 	}
 
-	h.Set("Content-Type", "text/csv;version=1")
-
 	v := r.URL.Query()
 
 	typeID := v.Get("typeID")
@@ -37,14 +36,16 @@ func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	networkID := v.Get("networkID")
 	siteID := v.Get("siteID")
 
-	var days int
+	// check the site exists so an unknown site is a 404 for all formats
+	// rather than an empty result.
+	if res = validSite(networkID, siteID); !res.Ok {
+		return res
+	}
 
-	if v.Get("days") != "" {
-		var err error
-		days, err = strconv.Atoi(v.Get("days"))
-		if err != nil || days > 365000 {
-			return weft.BadRequest("Invalid days query param.")
-		}
+	var start, end time.Time
+
+	if start, end, res = getTimeRange(v); !res.Ok {
+		return res
 	}
 
 	var methodID string
@@ -56,6 +57,59 @@ func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		}
 	}
 
+	var systemID, sampleID string
+
+	if systemID, sampleID, res = getSample(v); !res.Ok {
+		return res
+	}
+
+	var showSample bool
+
+	if showSample, res = getShowSample(v); !res.Ok {
+		return res
+	}
+
+	var showQC bool
+
+	if showQC, res = getShowQC(v); !res.Ok {
+		return res
+	}
+
+	var agg aggQ
+
+	if agg, res = getAggregate(v); !res.Ok {
+		return res
+	}
+
+	if agg.on() && showSample {
+		return weft.BadRequest("showSample can not be used with interval.")
+	}
+
+	if agg.on() && showQC {
+		return weft.BadRequest("showQC can not be used with interval.")
+	}
+
+	var qc []string
+
+	if qc, res = getQC(v); !res.Ok {
+		return res
+	}
+
+	var corrected bool
+
+	if corrected, res = getCorrected(v); !res.Ok {
+		return res
+	}
+
+	var asOf time.Time
+
+	if v.Get("asOf") != "" {
+		var err error
+		if asOf, err = time.Parse(time.RFC3339, v.Get("asOf")); err != nil {
+			return weft.BadRequest("Invalid asOf query param.")
+		}
+	}
+
 	var err error
 
 	// Find the unit for the CSV header
@@ -68,62 +122,62 @@ func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return weft.ServiceUnavailableError(err)
 	}
 
+	var u unitQ
+
+	if u, res = getUnit(v, typeID, unit); !res.Ok {
+		return res
+	}
+
+	unit = u.symbol
+
+	q := obsQ{
+		networkID: networkID,
+		siteID:    siteID,
+		typeID:    typeID,
+		methodID:  methodID,
+		systemID:  systemID,
+		sampleID:  sampleID,
+		start:     start,
+		end:       end,
+		asOf:      asOf,
+		qc:        qc,
+		corrected: corrected,
+		unit:      u,
+	}
+
+	if r.Header.Get("Accept") == v1JSON {
+		return observationJSON(q, agg, showSample, h, b)
+	}
+
+	h.Set("Content-Type", v1CSV)
+
+	where, args := q.where()
+
 	var d string
 	var rows *sql.Rows
 
+	// qc is opt-in and after any sample columns so the CSV columns are unchanged for clients that do not ask for it.
+	var qcCol string
+	if showQC {
+		qcCol = `, qc`
+	}
+
 	switch {
-	case days == 0 && methodID == "":
+	case agg.on():
 		rows, err = db.Query(
-			`SELECT format('%s,%s,%s', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) as csv FROM fits.observation 
-                           WHERE 
-                               sitepk = (
-                                              SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-                                            )
-                               AND typepk = (
-                                                        SELECT typepk FROM fits.type WHERE typeid = $3
-                                                       ) 
-                                 ORDER BY time ASC;`, networkID, siteID, typeID)
-	case days != 0 && methodID == "":
+			`SELECT format('%s,%s,%s', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) as csv FROM `+agg.from(q.table(), where)+`
+			ORDER BY time ASC;`, args...)
+	case !showSample:
 		rows, err = db.Query(
-			`SELECT format('%s,%s,%s', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) as csv FROM fits.observation 
-                           WHERE 
-                               sitepk = (
-                                              SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-                                            )
-                               AND typepk = (
-                                                        SELECT typepk FROM fits.type WHERE typeid = $3
-                                                       ) 
-                                AND time > (now() - interval '`+strconv.Itoa(days)+` days')
-                  		ORDER BY time ASC;`, networkID, siteID, typeID)
-	case days == 0 && methodID != "":
+			`SELECT concat_ws(',', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error`+qcCol+`) as csv FROM `+q.table()+`
+			`+where+`
+			ORDER BY time ASC;`, args...)
+	default:
 		rows, err = db.Query(
-			`SELECT format('%s,%s,%s', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) as csv FROM fits.observation 
-                           WHERE 
-                               sitepk = (
-                                              SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-                                            )
-                               AND typepk = (
-                                                         SELECT typepk FROM fits.type WHERE typeid = $3
-                                                       ) 
-			AND methodpk = (
-					SELECT methodpk FROM fits.method WHERE methodid = $4
-				)
-                                 ORDER BY time ASC;`, networkID, siteID, typeID, methodID)
-	case days != 0 && methodID != "":
-		rows, err = db.Query(
-			`SELECT format('%s,%s,%s', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) as csv FROM fits.observation 
-                           WHERE 
-                               sitepk = (
-                                              SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-                                            )
-                               AND typepk = (
-                                                         SELECT typepk FROM fits.type WHERE typeid = $3
-                                                       ) 
-		AND methodpk = (
-					SELECT methodpk FROM fits.method WHERE methodid = $4
-				)
-                                AND time > (now() - interval '`+strconv.Itoa(days)+` days')
-                  		ORDER BY time ASC;`, networkID, siteID, typeID, methodID)
+			`SELECT concat_ws(',', to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error, sampleid, systemid`+qcCol+`) as csv
+			FROM `+q.table()+` join fits.sample using (samplepk) join fits.system using (systempk)
+			`+where+`
+			ORDER BY time ASC;`, args...)
 	}
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
@@ -131,6 +185,12 @@ func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	defer rows.Close()
 
 	b.Write([]byte("date-time, " + typeID + " (" + unit + "), error (" + unit + ")"))
+	if showSample {
+		b.Write([]byte(", sampleID, systemID"))
+	}
+	if showQC {
+		b.Write([]byte(", qc"))
+	}
 	b.Write(eol)
 	for rows.Next() {
 		err := rows.Scan(&d)
@@ -151,8 +211,65 @@ func observation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	return &weft.StatusOK
 }
 
+/*
+observationJSON writes the observations for q, aggregated by agg, to b as a JSON array of value.
+If showSample is true the sampleID and systemID are included for each value.
+The qc flag is included unless the observations are aggregated.
+*/
+func observationJSON(q obsQ, agg aggQ, showSample bool, h http.Header, b *bytes.Buffer) *weft.Result {
+	h.Set("Content-Type", v1JSON)
+
+	where, args := q.where()
+
+	var rows *sql.Rows
+	var err error
+
+	switch {
+	case showSample:
+		rows, err = db.Query(`SELECT time, value, error, qc, sampleid, systemid
+		FROM `+q.table()+` join fits.sample using (samplepk) join fits.system using (systempk)
+		`+where+`
+		ORDER BY time ASC;`, args...)
+	case agg.on():
+		rows, err = db.Query(`SELECT time, value, error, '', '', '' FROM `+agg.from(q.table(), where)+`
+		ORDER BY time ASC;`, args...)
+	default:
+		rows, err = db.Query(`SELECT time, value, error, qc, '', '' FROM `+q.table()+`
+		`+where+`
+		ORDER BY time ASC;`, args...)
+	}
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer rows.Close()
+
+	values := []value{}
+
+	for rows.Next() {
+		var o value
+
+		if err = rows.Scan(&o.T, &o.V, &o.E, &o.QC, &o.SampleID, &o.SystemID); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		values = append(values, o)
+	}
+	if err = rows.Err(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	by, err := json.Marshal(values)
+	if err != nil {
+		return weft.InternalServerError(err)
+	}
+
+	b.Write(by)
+
+	return &weft.StatusOK
+}
+
 func observationStats(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"siteID", "networkID", "typeID"}, []string{"days", "methodID"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"siteID", "networkID", "typeID"}, []string{"days", "start", "end", "methodID", "percentiles", "outliers", "threshold", "window", "unit"}); !res.Ok {
 		return res
 	}
 
@@ -167,17 +284,22 @@ func observationStats(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Res
 		return res
 	}
 
-	var days int
-	var err error
-	var tmin, tmax time.Time
+	var start, end time.Time
 
-	if v.Get("days") != "" {
-		days, err = strconv.Atoi(v.Get("days"))
-		if err != nil || days > 365000 {
-			weft.BadRequest("Invalid days query param.")
-		}
-		tmax = time.Now().UTC()
-		tmin = tmax.Add(time.Duration(days*-1) * time.Hour * 24)
+	if start, end, res = getTimeRange(v); !res.Ok {
+		return res
+	}
+
+	var pc []float64
+
+	if pc, res = getPercentiles(v); !res.Ok {
+		return res
+	}
+
+	var o outlierQ
+
+	if o, res = getOutliers(v, ""); !res.Ok {
+		return res
 	}
 
 	var methodID string
@@ -198,28 +320,52 @@ func observationStats(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Res
 		return weft.ServiceUnavailableError(err)
 	}
 
-	networkID := v.Get("networkID")
-	siteID := v.Get("siteID")
+	var u unitQ
+
+	if u, res = getUnit(v, typeID, unit); !res.Ok {
+		return res
+	}
 
-	values, err := loadObs(networkID, siteID, typeID, methodID, tmin)
+	q := obsQ{
+		networkID: v.Get("networkID"),
+		siteID:    v.Get("siteID"),
+		typeID:    typeID,
+		methodID:  methodID,
+		start:     start,
+		end:       end,
+		unit:      u,
+	}
+
+	values, err := loadObs(q)
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
-	mean, stdDev, err := stddevPop(networkID, siteID, typeID, methodID, tmin)
+	mean, stdDev, err := stddevPop(q)
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
-	stats := obstats{Unit: unit,
+	stats := obstats{Unit: u.symbol,
 		Mean:             mean,
-		StddevPopulation: stdDev}
+		StddevPopulation: stdDev,
+		Percentiles:      []percentile{}}
 
-	stats.First = values[0]
-	stats.Last = values[len(values)-1]
+	stats.summarise(values, pc)
 
-	iMin, iMax, _ := extremes(values)
-	stats.Minimum = values[iMin]
-	stats.Maximum = values[iMax]
+	if o.on() {
+		stats.Outliers = &outliers{Method: o.method, Threshold: o.threshold, Window: o.window, Values: []value{}}
+
+		f := make([]float64, len(values))
+		for i := range values {
+			f[i] = values[i].V
+		}
+
+		for _, i := range o.find(f, mean, stdDev) {
+			stats.Outliers.Values = append(stats.Outliers.Values, values[i])
+		}
+
+		stats.Outliers.Count = len(stats.Outliers.Values)
+	}
 
 	by, err := json.Marshal(stats)
 	if err != nil {
@@ -237,7 +383,7 @@ func observationStats(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Res
  * for multiple sites, return the daily average values
  */
 func observationResults(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"siteID", "typeID"}, []string{}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"siteID", "typeID"}, []string{"interval", "aggregate"}); !res.Ok {
 		return res
 	}
 
@@ -245,149 +391,111 @@ func observationResults(r *http.Request, h http.Header, b *bytes.Buffer) *weft.R
 
 	v := r.URL.Query()
 
+	agg, res := getAggregate(v)
+	if !res.Ok {
+		return res
+	}
+
 	typeID := v.Get("typeID")
-	siteID := v.Get("siteID")
-	siteIDs := strings.Split(siteID, ",")
+	siteIDs := strings.Split(v.Get("siteID"), ",")
 
-	queryWhereClause := " where type.typeid='" + typeID + "' and site.siteid in ("
-	for index, id := range siteIDs {
-		if index > 0 {
-			queryWhereClause += ","
+	//multiple site, aggregate results on daily average unless an interval is set
+	dateFormat := `'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'`
+	if len(siteIDs) > 1 {
+		if !agg.on() {
+			agg, _ = getAggregate(url.Values{"interval": []string{"1d"}})
 		}
-		queryWhereClause += "'" + id + "'"
-	}
-	queryWhereClause += ")"
 
-	b.WriteString("{\"param\":\"" + typeID + "\",")
-	b.WriteString("\"sites\":[")
-	for index, siteId := range siteIDs {
-		if index > 0 {
-			b.WriteString(",")
+		if agg.dateOnly {
+			dateFormat = `'YYYY-MM-DD'`
 		}
-		b.WriteString("\"" + siteId + "\"")
-
 	}
-	b.WriteString("],")
 
-	//4. query results (2 different situations)
-	b.WriteString("\"results\": [")
-	if len(siteIDs) == 1 {
-		//single site
-		//4.1 query results values
-		rows, err := db.Query(
-			`select  to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') as date, site.siteid, value, error, site.name as sitename from fits.observation obs
-       left outer join fits.type type on obs.typepk = type.typepk
-       left outer join fits.site site on obs.sitepk = site.sitepk ` + queryWhereClause + ` order by date;`)
-		if err != nil {
-			return weft.ServiceUnavailableError(err)
-		}
-		defer rows.Close()
-		index1 := 0
-		for rows.Next() {
-			var (
-				dateStr  string
-				siteId   string
-				siteName string
-				val      float64
-				stdErr   float64
-			)
-
-			err := rows.Scan(&dateStr, &siteId, &val, &stdErr, &siteName)
-			if err != nil {
-				return weft.ServiceUnavailableError(err)
-			}
-			if index1 > 0 {
-				b.WriteString(",")
-			}
-			//date
-			b.WriteString("[\"" + dateStr + "\",")
-			//values
-			b.WriteString("[")
-			b.WriteString(strconv.FormatFloat(val, 'f', -1, 64) + "," + strconv.FormatFloat(stdErr, 'f', -1, 64))
-			b.WriteString("]")
-			b.WriteString("]")
-			index1++
+	// Read the results for all sites from the DB before writing any of the response.
+	// Then if there is an error we can let the client know without sending
+	// a partial data response.
+	var results []siteValue
 
+	for _, siteID := range siteIDs {
+		q := obsQ{
+			siteID: siteID,
+			typeID: typeID,
 		}
-		rows.Close()
 
-	} else if len(siteIDs) > 1 {
-		//multiple site, aggregate results on daily average
-		//4.1. Find dates
-		rows, err := db.Query(
-			`select  distinct to_char(time, 'YYYY-MM-DD') as date from fits.observation obs
-     left outer join fits.type type on obs.typepk = type.typepk
-     left outer join fits.site site on obs.sitepk = site.sitepk ` + queryWhereClause + ` order by date;`)
+		where, args := q.where()
 
+		rows, err := db.Query(`SELECT to_char(time, `+dateFormat+`), value, error FROM `+agg.from(q.table(), where)+`
+			ORDER BY time ASC`, args...)
 		if err != nil {
 			return weft.ServiceUnavailableError(err)
 		}
-		defer rows.Close()
 
-		// Use a buffer for reading the data from the DB.  Then if a there
-		// is an error we can let the client know without sending
-		// a partial data response.
-		var d string
-		var dates []string
 		for rows.Next() {
-			err := rows.Scan(&d)
-			if err != nil {
+			r := siteValue{siteID: siteID}
+
+			if err = rows.Scan(&r.date, &r.V, &r.E); err != nil {
+				rows.Close()
 				return weft.ServiceUnavailableError(err)
 			}
-			dates = append(dates, d)
+
+			results = append(results, r)
+		}
+		if err = rows.Err(); err != nil {
+			rows.Close()
+			return weft.ServiceUnavailableError(err)
 		}
 		rows.Close()
+	}
 
-		//4.2. query results
-		rows, err = db.Query(
-			`select agt.*, site1.name as sitename from (
-       select  to_char(time, 'YYYY-MM-DD') as date, site.siteid, avg(value) as value, avg(error) as error  from fits.observation obs
-       left outer join fits.type type on obs.typepk = type.typepk
-       left outer join fits.site site on obs.sitepk = site.sitepk ` + queryWhereClause + ` group by date, siteid) agt
-       left outer join fits.site site1 on agt.siteid = site1.siteid
-       order by agt.date, agt.siteid;`)
+	b.WriteString("{\"param\":\"" + typeID + "\",")
+	b.WriteString("\"sites\":[")
+	for index, siteId := range siteIDs {
+		if index > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\"" + siteId + "\"")
 
-		if err != nil {
-			return weft.ServiceUnavailableError(err)
+	}
+	b.WriteString("],")
+
+	b.WriteString("\"results\": [")
+	if len(siteIDs) == 1 {
+		//single site, all the results in time order.
+		for index1, r := range results {
+			if index1 > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("[\"" + r.date + "\",[")
+			b.WriteString(strconv.FormatFloat(r.V, 'f', -1, 64) + "," + strconv.FormatFloat(r.E, 'f', -1, 64))
+			b.WriteString("]]")
 		}
-		defer rows.Close()
+	} else {
+		//multiple sites, one row per date with a value or null for each site.
 		//the result map key as siteid + date string
 		resultsMap := make(map[string]value)
-		for rows.Next() {
-			var (
-				dateStr  string
-				siteId   string
-				siteName string
-				val      float64
-				stdErr   float64
-			)
-
-			err := rows.Scan(&dateStr, &siteId, &val, &stdErr, &siteName)
-			if err != nil {
-				return weft.ServiceUnavailableError(err)
+		var dates []string
+
+		for _, r := range results {
+			if _, ok := resultsMap[r.siteID+"_"+r.date]; !ok {
+				dates = append(dates, r.date)
 			}
-			t1, e := time.Parse(
-				time.RFC3339,
-				dateStr+"T00:00:00+00:00")
+			resultsMap[r.siteID+"_"+r.date] = r.value
+		}
 
-			resultVal := value{T: t1,
-				V: val,
-				E: stdErr}
+		// the dates are all in the same format so this sorts them in time order.
+		sort.Strings(dates)
 
-			if e != nil {
-				log.Fatal("time parse error", e)
+		index1 := 0
+		for i, dateStr := range dates {
+			if i > 0 && dates[i-1] == dateStr {
 				continue
 			}
-			resultsMap[siteId+"_"+dateStr] = resultVal
-
-		}
-		rows.Close()
 
-		//4.3. assemble results
-		for index1, dateStr := range dates {
 			if index1 > 0 {
 				b.WriteString(",")
 			}
+			index1++
+
 			//date
 			b.WriteString("[\"" + dateStr + "\",")
 			for index2, siteId := range siteIDs {
@@ -406,7 +514,6 @@ func observationResults(r *http.Request, h http.Header, b *bytes.Buffer) *weft.R
 			}
 			b.WriteString("]")
 		}
-
 	}
 	b.WriteString("]")
 	b.WriteString("}")
@@ -414,130 +521,70 @@ func observationResults(r *http.Request, h http.Header, b *bytes.Buffer) *weft.R
 	return &weft.StatusOK
 }
 
+// siteValue is an observation result for siteID with the time formatted as date.
+type siteValue struct {
+	siteID, date string
+	value
+}
+
+/*
+obstats are summary statistics for observations.  Maximum, Minimum, First, Last,
+and LargestGap are null if there are not enough observations.  Times are in seconds.
+*/
 type obstats struct {
-	Maximum          value
-	Minimum          value
-	First            value
-	Last             value
-	Mean             float64
-	StddevPopulation float64
-	Unit             string
+	Maximum               *value
+	Minimum               *value
+	First                 *value
+	Last                  *value
+	Mean                  float64
+	StddevPopulation      float64
+	Unit                  string
+	Count                 int
+	Median                float64
+	Percentiles           []percentile
+	MAD                   float64 // median absolute deviation from the median.
+	StddevSample          float64
+	SpanSeconds           float64 // time from First to Last.
+	MedianIntervalSeconds float64 // median time between consecutive observations.
+	LargestGap            *gap
+	Outliers              *outliers `json:",omitempty"` // only if the outliers query parameter is set.
+}
+
+type percentile struct {
+	Percentile float64
+	Value      float64
+}
+
+// gap is the time between two consecutive observations.
+type gap struct {
+	Start   time.Time
+	End     time.Time
+	Seconds float64
 }
 
 /*
-stddevPop finds the mean and population stddev for the networkID, siteID, and typeID query.
-The start of data range can be restricted using the start parameter.  To query all data pass
-a zero value uninitialized Time.
+stddevPop finds the mean and population stddev for the observations matching q.
+Both are zero if there are no observations.
 */
-func stddevPop(networkID, siteID, typeID string, methodID string, start time.Time) (m, d float64, err error) {
-	tZero := time.Time{}
+func stddevPop(q obsQ) (m, d float64, err error) {
+	where, args := q.where()
 
-	switch {
-	case start == tZero && methodID == "":
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-         WHERE
-         sitepk = (SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1)
-         AND typepk = ( SELECT typepk FROM fits.type WHERE typeid = $3 )`,
-			networkID, siteID, typeID).Scan(&m, &d)
-
-	case start != tZero && methodID == "":
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-          WHERE
-          sitepk = (SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1)
-	      AND typepk = (SELECT typepk FROM fits.type WHERE typeid = $3 )
-	      AND time > $4`,
-			networkID, siteID, typeID, start).Scan(&m, &d)
-
-	case start == tZero && methodID != "":
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-         WHERE
-         sitepk = (SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1)
-	      AND typepk = ( SELECT typepk FROM fits.type WHERE typeid = $3)
-	      AND methodpk = (SELECT methodpk FROM fits.method WHERE methodid = $4 )`,
-			networkID, siteID, typeID, methodID).Scan(&m, &d)
-
-	case start != tZero && methodID != "":
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-         WHERE
-         sitepk = ( SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 )
-         AND typepk = ( SELECT typepk FROM fits.type WHERE typeid = $3 )
-         AND methodpk = ( SELECT methodpk FROM fits.method WHERE methodid = $5 )
-         AND time > $4`,
-			networkID, siteID, typeID, start, methodID).Scan(&m, &d)
-	}
+	err = db.QueryRow(`SELECT COALESCE(avg(value), 0), COALESCE(stddev_pop(value), 0) FROM `+q.table()+`
+		`+where, args...).Scan(&m, &d)
 
 	return
 }
 
 /*
-loadObs returns observation values for  the networkID, siteID, and typeID query.
-The start of data range can be restricted using the start parameter.  To query all data pass
-a zero value uninitialized Time.
-Passing a non zero methodID will further restrict the result set.
+loadObs returns observation values matching q.
 []values is ordered so the latest value will always be values[len(values) -1]
 */
-func loadObs(networkID, siteID, typeID, methodID string, start time.Time) (values []value, err error) {
-	var rows *sql.Rows
-	tZero := time.Time{}
+func loadObs(q obsQ) (values []value, err error) {
+	where, args := q.where()
 
-	switch {
-	case start == tZero && methodID == "":
-		rows, err = db.Query(
-			`SELECT time, value, error FROM fits.observation 
-		WHERE 
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	ORDER BY time ASC;`, networkID, siteID, typeID)
-	case start != tZero && methodID == "":
-		rows, err = db.Query(
-			`SELECT time, value, error FROM fits.observation 
-		WHERE 
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		) 
-	AND time > $4
-	ORDER BY time ASC;`, networkID, siteID, typeID, start)
-	case start == tZero && methodID != "":
-		rows, err = db.Query(
-			`SELECT time, value, error FROM fits.observation 
-		WHERE 
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	AND methodpk = (
-			SELECT methodpk FROM fits.method WHERE methodid = $4
-			)	
-	ORDER BY time ASC;`, networkID, siteID, typeID, methodID)
-	case start != tZero && methodID != "":
-		rows, err = db.Query(
-			`SELECT time, value, error FROM fits.observation 
-		WHERE 
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1 
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		) 
-	AND methodpk = (
-		SELECT methodpk FROM fits.method WHERE methodid = $5
-		)	
-	AND time > $4
-	ORDER BY time ASC;`, networkID, siteID, typeID, start, methodID)
-	}
+	rows, err := db.Query(`SELECT time, value, error FROM `+q.table()+`
+		`+where+`
+		ORDER BY time ASC;`, args...)
 	if err != nil {
 		return
 	}
@@ -582,7 +629,10 @@ func extremes(values []value) (min, max int, hasErrors bool) {
 }
 
 type value struct {
-	T time.Time `json:"DateTime"`
-	V float64   `json:"Value"`
-	E float64   `json:"Error"`
+	T        time.Time `json:"DateTime"`
+	V        float64   `json:"Value"`
+	E        float64   `json:"Error"`
+	QC       string    `json:"QC,omitempty"`
+	SampleID string    `json:"SampleID,omitempty"`
+	SystemID string    `json:"SystemID,omitempty"`
 }
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/GeoNet/weft"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// obsColumns are the columns, in order, for adding observations as CSV.
+var obsColumns = []string{"networkID", "siteID", "typeID", "methodID", "sampleID", "systemID", "time", "value", "error"}
+
+// obsRow is an observation to be added to the DB.
+type obsRow struct {
+	NetworkID string   `json:"networkID"`
+	SiteID    string   `json:"siteID"`
+	TypeID    string   `json:"typeID"`
+	MethodID  string   `json:"methodID"`
+	SampleID  string   `json:"sampleID"`
+	SystemID  string   `json:"systemID"`
+	Time      string   `json:"time"`
+	Value     *float64 `json:"value"`
+	Error     *float64 `json:"error"`
+	t         time.Time
+}
+
+// rowResult reports the outcome for one row in a request to add observations.
+// Row numbers start at 1 for the first observation (not counting any CSV header).
+type rowResult struct {
+	Row     int    `json:"row"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type addReport struct {
+	Added    int         `json:"added"`
+	Rejected int         `json:"rejected"`
+	Rows     []rowResult `json:"rows"`
+}
+
+/*
+observationAdd adds or updates observations in the DB with the same semantics as fits.add_observation.
+The request body is either a JSON array of observations (Content-Type application/json;version=1)
+or CSV (Content-Type text/csv;version=1) with a header line naming the columns in obsColumns.
+
+Each row is validated.  Invalid rows are rejected, valid rows are added in a single transaction.
+The basic auth user is recorded as who made any changes.  The response reports the result for each row.
+*/
+func observationAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var rows []obsRow
+	var report addReport
+	var res *weft.Result
+
+	switch r.Header.Get("Content-Type") {
+	case v1JSON:
+		rows, report.Rows, res = readObsJSON(r.Body)
+	case v1CSV:
+		rows, report.Rows, res = readObsCSV(r.Body)
+	default:
+		return weft.BadRequest("Content-Type must be " + v1JSON + " or " + v1CSV)
+	}
+	if !res.Ok {
+		return res
+	}
+
+	c := make(validCache)
+
+	for i := range rows {
+		if report.Rows[i].Status != "" {
+			continue
+		}
+
+		var msg string
+		if msg, res = c.obsError(rows[i]); res != nil {
+			return res
+		}
+		if msg != "" {
+			report.Rows[i] = rowResult{Row: i + 1, Status: "rejected", Message: msg}
+		}
+	}
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`SELECT fits.add_observation($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer stmt.Close()
+
+	user, _, _ := r.BasicAuth()
+
+	for i, o := range rows {
+		if report.Rows[i].Status != "" {
+			report.Rejected++
+			continue
+		}
+
+		if _, err = stmt.Exec(o.NetworkID, o.SiteID, o.TypeID, o.MethodID, o.SampleID, o.SystemID, o.t, *o.Value, *o.Error, user); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		report.Rows[i] = rowResult{Row: i + 1, Status: "ok"}
+		report.Added++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	by, err := json.Marshal(report)
+	if err != nil {
+		return weft.InternalServerError(err)
+	}
+
+	h.Set("Content-Type", v1JSON)
+	b.Write(by)
+
+	return &weft.StatusOK
+}
+
+/*
+readObsJSON reads a JSON array of observations from r.  Rows that can't be parsed are
+marked rejected in the returned []rowResult.  A non ok *weft.Result is returned if r
+can't be read as a JSON array.
+*/
+func readObsJSON(r io.Reader) ([]obsRow, []rowResult, *weft.Result) {
+	var raw []json.RawMessage
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, weft.BadRequest("invalid JSON: " + err.Error())
+	}
+
+	if len(raw) == 0 {
+		return nil, nil, weft.BadRequest("no observations in request")
+	}
+
+	rows := make([]obsRow, len(raw))
+	results := make([]rowResult, len(raw))
+
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], &rows[i]); err != nil {
+			results[i] = rowResult{Row: i + 1, Status: "rejected", Message: "invalid JSON: " + err.Error()}
+			continue
+		}
+
+		if msg := rows[i].parse(); msg != "" {
+			results[i] = rowResult{Row: i + 1, Status: "rejected", Message: msg}
+		}
+	}
+
+	return rows, results, &weft.StatusOK
+}
+
+/*
+readObsCSV reads CSV observations from r.  The first line must be a header
+naming the columns in obsColumns (in any order).  Rows that can't be parsed are
+marked rejected in the returned []rowResult.
+*/
+func readObsCSV(r io.Reader) ([]obsRow, []rowResult, *weft.Result) {
+	c := csv.NewReader(r)
+	c.TrimLeadingSpace = true
+
+	header, err := c.Read()
+	if err != nil {
+		return nil, nil, weft.BadRequest("invalid CSV header: " + err.Error())
+	}
+
+	col := make(map[string]int)
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+
+	for _, k := range obsColumns {
+		if _, ok := col[k]; !ok {
+			return nil, nil, weft.BadRequest("CSV header missing column: " + k)
+		}
+	}
+
+	c.FieldsPerRecord = len(header)
+
+	var rows []obsRow
+	var results []rowResult
+
+	for {
+		rec, err := c.Read()
+		if err == io.EOF {
+			break
+		}
+
+		i := len(rows)
+		rows = append(rows, obsRow{})
+		results = append(results, rowResult{})
+
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, nil, weft.BadRequest("invalid CSV: " + err.Error())
+			}
+			results[i] = rowResult{Row: i + 1, Status: "rejected", Message: err.Error()}
+			continue
+		}
+
+		o := obsRow{
+			NetworkID: rec[col["networkID"]],
+			SiteID:    rec[col["siteID"]],
+			TypeID:    rec[col["typeID"]],
+			MethodID:  rec[col["methodID"]],
+			SampleID:  rec[col["sampleID"]],
+			SystemID:  rec[col["systemID"]],
+			Time:      rec[col["time"]],
+		}
+
+		var msg string
+
+		if o.Value, msg = parseCSVFloat("value", rec[col["value"]]); msg == "" {
+			o.Error, msg = parseCSVFloat("error", rec[col["error"]])
+		}
+
+		if msg == "" {
+			msg = o.parse()
+		}
+
+		rows[i] = o
+
+		if msg != "" {
+			results[i] = rowResult{Row: i + 1, Status: "rejected", Message: msg}
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, weft.BadRequest("no observations in request")
+	}
+
+	return rows, results, &weft.StatusOK
+}
+
+func parseCSVFloat(name, s string) (*float64, string) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, "invalid " + name + ": " + s
+	}
+	return &f, ""
+}
+
+// parse checks o has all fields set and parses the time.  Returns a non empty
+// message describing the first problem found.
+func (o *obsRow) parse() string {
+	for _, f := range []struct{ name, val string }{
+		{"networkID", o.NetworkID},
+		{"siteID", o.SiteID},
+		{"typeID", o.TypeID},
+		{"methodID", o.MethodID},
+		{"sampleID", o.SampleID},
+		{"systemID", o.SystemID},
+		{"time", o.Time},
+	} {
+		if f.val == "" {
+			return "missing " + f.name
+		}
+	}
+
+	switch {
+	case o.Value == nil:
+		return "missing value"
+	case o.Error == nil:
+		return "missing error"
+	case math.IsNaN(*o.Value) || math.IsInf(*o.Value, 0):
+		return "invalid value"
+	case math.IsNaN(*o.Error) || math.IsInf(*o.Error, 0) || *o.Error < 0:
+		return "invalid error"
+	}
+
+	var err error
+	if o.t, err = time.Parse(time.RFC3339Nano, o.Time); err != nil {
+		return "invalid time: " + o.Time
+	}
+
+	return ""
+}
+
+// validCache caches the results of validity checks against the DB so that
+// a batch of observations doesn't repeat the same queries.
+type validCache map[string]*weft.Result
+
+/*
+check runs f, or finds the cached result for key.  Returns msg if f returns
+weft.NotFound.  Returns a non nil *weft.Result for other errors.
+*/
+func (c validCache) check(key, msg string, f func() *weft.Result) (string, *weft.Result) {
+	res, ok := c[key]
+	if !ok {
+		res = f()
+		c[key] = res
+	}
+
+	switch {
+	case res.Ok:
+		return "", nil
+	case res.Code == http.StatusNotFound:
+		return msg, nil
+	default:
+		return "", res
+	}
+}
+
+/*
+obsError checks that the site, type, method, and sample for o exist in the DB.
+Returns a non empty message if they don't.  A non nil *weft.Result is
+returned for errors that should stop processing.
+*/
+func (c validCache) obsError(o obsRow) (string, *weft.Result) {
+	var msg string
+	var res *weft.Result
+
+	if msg, res = c.check("site."+o.NetworkID+"."+o.SiteID,
+		fmt.Sprintf("unknown site %s.%s", o.NetworkID, o.SiteID),
+		func() *weft.Result { return validSite(o.NetworkID, o.SiteID) }); msg != "" || res != nil {
+		return msg, res
+	}
+
+	if msg, res = c.check("type."+o.TypeID,
+		"unknown typeID "+o.TypeID,
+		func() *weft.Result { return validType(o.TypeID) }); msg != "" || res != nil {
+		return msg, res
+	}
+
+	if msg, res = c.check("method."+o.TypeID+"."+o.MethodID,
+		fmt.Sprintf("methodID %s is not valid for typeID %s", o.MethodID, o.TypeID),
+		func() *weft.Result { return validTypeMethod(o.TypeID, o.MethodID) }); msg != "" || res != nil {
+		return msg, res
+	}
+
+	return c.check("sample."+o.SystemID+"."+o.SampleID,
+		fmt.Sprintf("unknown sample %s for system %s", o.SampleID, o.SystemID),
+		func() *weft.Result { return validSample(o.SystemID, o.SampleID) })
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestObservationAdd(t *testing.T) {
+	setup()
+	defer teardown()
+
+	in := []struct {
+		id, contentType, body string
+		added, rejected       int
+	}{
+		{
+			id:          "json",
+			contentType: v1JSON,
+			body: `[{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2002-01-08T12:00:00Z","value":1.1,"error":0.1},
+			{"networkID":"TN1","siteID":"NOSITE","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2002-01-08T12:00:00Z","value":1.1,"error":0.1},
+			{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m2","sampleID":"none","systemID":"none","time":"2002-01-08T12:00:00Z","value":1.1,"error":0.1},
+			{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2002-01-08","value":1.1,"error":0.1},
+			{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2002-01-09T12:00:00Z","error":0.1}]`,
+			added:    1,
+			rejected: 4,
+		},
+		{
+			id:          "csv",
+			contentType: v1CSV,
+			body: `networkID,siteID,typeID,methodID,sampleID,systemID,time,value,error
+TN1,TEST3,t2,m1,none,none,2002-01-10T12:00:00Z,1.2,0.1
+TN1,TEST3,t2,m1,none,none,2002-01-11T12:00:00Z,bob,0.1
+TN1,TEST3,t2,m1,nosample,none,2002-01-11T12:00:00Z,1.3,0.1
+`,
+			added:    1,
+			rejected: 2,
+		},
+	}
+
+	for _, v := range in {
+		req, err := http.NewRequest("POST", testServer.URL+"/observation", strings.NewReader(v.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", v.contentType)
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200 got %d", v.id, res.StatusCode)
+		}
+
+		var r addReport
+		err = json.NewDecoder(res.Body).Decode(&r)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", v.id, err)
+		}
+
+		if r.Added != v.added {
+			t.Errorf("%s: expected %d added got %d", v.id, v.added, r.Added)
+		}
+
+		if r.Rejected != v.rejected {
+			t.Errorf("%s: expected %d rejected got %d", v.id, v.rejected, r.Rejected)
+		}
+
+		if len(r.Rows) != v.added+v.rejected {
+			t.Errorf("%s: expected %d rows in report got %d", v.id, v.added+v.rejected, len(r.Rows))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"strconv"
+)
+
+type qcReport struct {
+	Flagged int64 `json:"flagged"`
+}
+
+/*
+observationQC sets the qc flag for the observations for a site and type between start and end.
+The range can be restricted by methodID, systemID, and sampleID.  The previous version of each changed
+observation is kept in fits.observation_revision with the basic auth user recorded as who made the change.
+The response reports the number of observations that were flagged.
+*/
+func observationQC(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID", "typeID", "start", "end", "qc"}, []string{"methodID", "systemID", "sampleID"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	qc := v.Get("qc")
+	if !validQC(qc) {
+		return weft.BadRequest("invalid qc query param.")
+	}
+
+	q := obsQ{
+		networkID: v.Get("networkID"),
+		siteID:    v.Get("siteID"),
+		typeID:    v.Get("typeID"),
+		methodID:  v.Get("methodID"),
+	}
+
+	var res *weft.Result
+
+	if q.start, q.end, res = getTimeRange(v); !res.Ok {
+		return res
+	}
+
+	if res = validSite(q.networkID, q.siteID); !res.Ok {
+		return res
+	}
+
+	if res = validType(q.typeID); !res.Ok {
+		return res
+	}
+
+	if q.methodID != "" {
+		if res = validTypeMethod(q.typeID, q.methodID); !res.Ok {
+			return res
+		}
+	}
+
+	if q.systemID, q.sampleID, res = getSample(v); !res.Ok {
+		return res
+	}
+
+	user, _, _ := r.BasicAuth()
+
+	where, args := q.where()
+	args = append(args, qc, user)
+	where += `
+	AND qc <> $` + strconv.Itoa(len(args)-1)
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	// keep the previous versions, the same as fits.add_observation.
+	if _, err = tx.Exec(`INSERT INTO fits.observation_revision (sitepk, typepk, methodpk, samplepk, time, value, error, qc,
+		published, published_by, replaced, replaced_by)
+		SELECT sitepk, typepk, methodpk, samplepk, time, value, error, qc, updated, updated_by, now(), $`+strconv.Itoa(len(args))+`
+		FROM fits.observation
+		`+where+`
+		FOR UPDATE`, args...); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	var rep qcReport
+	var u sql.Result
+
+	u, err = tx.Exec(`UPDATE fits.observation SET qc = $`+strconv.Itoa(len(args)-1)+`, updated = now(),
+		updated_by = $`+strconv.Itoa(len(args))+`, txid = txid_current(), change = nextval('fits.observation_change_seq')
+		`+where, args...)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if rep.Flagged, err = u.RowsAffected(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeJSON(rep, h, b)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestObservationQC(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove observations from any earlier test run.
+	if _, err := dbW.Exec(`DELETE FROM fits.observation WHERE time >= '2005-01-01T00:00:00Z' AND time < '2006-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbW.Exec(`DELETE FROM fits.observation_revision WHERE time >= '2005-01-01T00:00:00Z' AND time < '2006-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", testServer.URL+"/observation", strings.NewReader(
+		`[{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2005-01-08T12:00:00Z","value":1.1,"error":0.1},
+		{"networkID":"TN1","siteID":"TEST3","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2005-02-08T12:00:00Z","value":1.2,"error":0.1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", v1JSON)
+	req.SetBasicAuth("test", "test")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 got %d", res.StatusCode)
+	}
+
+	// flagging the same range twice should only change the observation once.
+	for i, expected := range []int64{1, 0} {
+		req, err = http.NewRequest("PUT", testServer.URL+
+			"/observation/qc?networkID=TN1&siteID=TEST3&typeID=t2&start=2005-01-01T00:00:00Z&end=2005-02-01T00:00:00Z&qc=bad", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth("test", "test")
+
+		res, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var q qcReport
+		err = json.NewDecoder(res.Body).Decode(&q)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if q.Flagged != expected {
+			t.Errorf("%d expected %d flagged got %d", i, expected, q.Flagged)
+		}
+	}
+
+	var values []value
+
+	getJSON(t, "/observation?networkID=TN1&siteID=TEST3&typeID=t2&start=2005-01-01T00:00:00Z&end=2006-01-01T00:00:00Z&qc=bad", &values)
+
+	if len(values) != 1 || values[0].QC != "bad" || values[0].V != 1.1 {
+		t.Errorf("expected one bad observation got %+v", values)
+	}
+
+	var versions []version
+
+	getJSON(t, "/observation/history?networkID=TN1&siteID=TEST3&typeID=t2&time=2005-01-08T12:00:00Z", &versions)
+
+	if len(versions) != 2 || versions[0].QC != "unverified" || versions[1].QC != "bad" {
+		t.Errorf("expected the qc change in the history got %+v", versions)
+	}
+}
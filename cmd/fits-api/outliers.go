@@ -0,0 +1,209 @@
+package main
+
+import (
+	"github.com/GeoNet/fits/internal/ts"
+	"github.com/GeoNet/weft"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultOutlierWindow = 11
+	maxOutlierWindow     = 1001
+	// madScale scales the MAD to estimate the standard deviation for normally distributed data.
+	madScale = 1.4826
+)
+
+var defaultThreshold = map[string]float64{
+	"mad":   3.5,
+	"sigma": 3.0,
+}
+
+/*
+outlierQ is the test for finding outliers.  The zero value finds no outliers.
+For mad a point is an outlier if it is more than threshold scaled MADs from the median
+of a rolling window of points centred on it.  For sigma a point is an outlier if it is
+more than threshold population standard deviations from the mean.
+*/
+type outlierQ struct {
+	method    string
+	threshold float64
+	window    int // points in the rolling window for mad.
+}
+
+// outliers are the outliers found in observations for /observation/stats.
+type outliers struct {
+	Method    string
+	Threshold float64
+	Window    int `json:",omitempty"`
+	Count     int
+	Values    []value
+}
+
+/*
+getHighlight returns the outlierQ for highlighting outliers on a plot with highlight=outliers.
+The default outlier test is mad.  The zero outlierQ is returned if highlight is not set.
+*/
+func getHighlight(v url.Values) (outlierQ, *weft.Result) {
+	switch v.Get("highlight") {
+	case "":
+		if v.Get("outliers") != "" || v.Get("threshold") != "" || v.Get("window") != "" {
+			return outlierQ{}, weft.BadRequest("outliers, threshold, and window can only be used with highlight=outliers.")
+		}
+		return outlierQ{}, &weft.StatusOK
+	case "outliers":
+		return getOutliers(v, "mad")
+	default:
+		return outlierQ{}, weft.BadRequest("invalid highlight query param.")
+	}
+}
+
+/*
+getOutliers returns the outlierQ for the outliers, threshold, and window query parameters.
+If outliers is empty then defaultMethod is used.  Use an empty defaultMethod for no outlier test
+unless the outliers query parameter is set.
+*/
+func getOutliers(v url.Values, defaultMethod string) (outlierQ, *weft.Result) {
+	o := outlierQ{method: v.Get("outliers")}
+
+	if o.method == "" {
+		o.method = defaultMethod
+	}
+
+	if o.method == "" {
+		if v.Get("threshold") != "" || v.Get("window") != "" {
+			return outlierQ{}, weft.BadRequest("threshold and window can only be used with outliers.")
+		}
+		return o, &weft.StatusOK
+	}
+
+	var ok bool
+
+	if o.threshold, ok = defaultThreshold[o.method]; !ok {
+		return outlierQ{}, weft.BadRequest("invalid outliers query param.")
+	}
+
+	if v.Get("threshold") != "" {
+		var err error
+		o.threshold, err = strconv.ParseFloat(v.Get("threshold"), 64)
+		if err != nil || math.IsNaN(o.threshold) || math.IsInf(o.threshold, 0) || o.threshold <= 0 {
+			return outlierQ{}, weft.BadRequest("invalid threshold query param.")
+		}
+	}
+
+	if o.method == "mad" {
+		o.window = defaultOutlierWindow
+
+		if v.Get("window") != "" {
+			var err error
+			o.window, err = strconv.Atoi(v.Get("window"))
+			if err != nil || o.window < 3 || o.window > maxOutlierWindow {
+				return outlierQ{}, weft.BadRequest("invalid window query param.")
+			}
+		}
+	} else if v.Get("window") != "" {
+		return outlierQ{}, weft.BadRequest("window can only be used with outliers=mad.")
+	}
+
+	return o, &weft.StatusOK
+}
+
+// on is true if o finds outliers.
+func (o outlierQ) on() bool {
+	return o.method != ""
+}
+
+/*
+find returns the indexes of the outliers in v.  v must be in time order for the mad test.
+mean and stddev are used for the sigma test.
+*/
+func (o outlierQ) find(v []float64, mean, stddev float64) []int {
+	var idx []int
+
+	switch o.method {
+	case "sigma":
+		if stddev == 0 {
+			return idx
+		}
+
+		for i := range v {
+			if math.Abs(v[i]-mean) > o.threshold*stddev {
+				idx = append(idx, i)
+			}
+		}
+	case "mad":
+		w := make([]float64, 0, o.window)
+		dev := make([]float64, 0, o.window)
+
+		for i := range v {
+			// the window is centred on i and shifted to stay within v at the ends.
+			s := i - o.window/2
+			if s+o.window > len(v) {
+				s = len(v) - o.window
+			}
+			if s < 0 {
+				s = 0
+			}
+			e := s + o.window
+			if e > len(v) {
+				e = len(v)
+			}
+
+			w = append(w[:0], v[s:e]...)
+			sort.Float64s(w)
+			m := quantile(w, 0.5)
+
+			dev = dev[:0]
+			for _, x := range w {
+				dev = append(dev, math.Abs(x-m))
+			}
+			sort.Float64s(dev)
+			mad := quantile(dev, 0.5)
+
+			if mad > 0 && math.Abs(v[i]-m) > o.threshold*madScale*mad {
+				idx = append(idx, i)
+			}
+		}
+	}
+
+	return idx
+}
+
+// highlightOutliers sets Highlight for the outliers in points found with o.  points must be in time order.
+func highlightOutliers(points []ts.Point, o outlierQ) {
+	if !o.on() {
+		return
+	}
+
+	v := make([]float64, len(points))
+	for i := range points {
+		v[i] = points[i].Value
+	}
+
+	m, d := meanStddevPop(v)
+
+	for _, i := range o.find(v, m, d) {
+		points[i].Highlight = true
+	}
+}
+
+// meanStddevPop returns the mean and population standard deviation of v.
+func meanStddevPop(v []float64) (m, d float64) {
+	if len(v) == 0 {
+		return
+	}
+
+	for i := range v {
+		m += v[i]
+	}
+	m /= float64(len(v))
+
+	for i := range v {
+		d += (v[i] - m) * (v[i] - m)
+	}
+	d = math.Sqrt(d / float64(len(v)))
+
+	return
+}
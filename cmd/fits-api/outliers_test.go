@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindOutliers(t *testing.T) {
+	v := []float64{10.1, 9.9, 10.0, 10.2, 9.8, 25.0, 10.1, 9.9, 10.0, 10.2, 9.8, 10.1, -5.0}
+
+	m, d := meanStddevPop(v)
+
+	for _, c := range []struct {
+		id       string
+		o        outlierQ
+		expected []int
+	}{
+		{"off", outlierQ{}, nil},
+		{"mad", outlierQ{method: "mad", threshold: 3.5, window: 5}, []int{5, 12}},
+		{"mad large window", outlierQ{method: "mad", threshold: 3.5, window: 101}, []int{5, 12}},
+		{"sigma", outlierQ{method: "sigma", threshold: 2.0}, []int{5, 12}},
+		{"sigma high threshold", outlierQ{method: "sigma", threshold: 5.0}, nil},
+	} {
+		idx := c.o.find(v, m, d)
+
+		if len(idx) != len(c.expected) {
+			t.Errorf("%s: expected %v got %v", c.id, c.expected, idx)
+			continue
+		}
+
+		for i := range idx {
+			if idx[i] != c.expected[i] {
+				t.Errorf("%s: expected %v got %v", c.id, c.expected, idx)
+			}
+		}
+	}
+
+	// constant values have no outliers.
+	if idx := (outlierQ{method: "mad", threshold: 3.5, window: 5}).find([]float64{1, 1, 1, 1, 1, 1}, 1, 0); len(idx) != 0 {
+		t.Errorf("expected no outliers for constant values got %v", idx)
+	}
+}
+
+func TestMeanStddevPop(t *testing.T) {
+	m, d := meanStddevPop([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+
+	if m != 5.0 {
+		t.Errorf("expected mean 5 got %f", m)
+	}
+
+	if math.Abs(d-2.0) > 1e-12 {
+		t.Errorf("expected stddev 2 got %f", d)
+	}
+}
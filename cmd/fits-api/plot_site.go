@@ -7,6 +7,7 @@ import (
 	"github.com/GeoNet/fits/internal/ts"
 	"github.com/GeoNet/weft"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -14,8 +15,141 @@ type plt struct {
 	ts.Plot
 }
 
+/*
+plotQ is the query parameters that are the same for plots of a site with one or more types.
+*/
+type plotQ struct {
+	plotType   string
+	format     string
+	layout     ts.Layout
+	start, end time.Time // the time range to query.  end is zero to query all observations after start.
+	xMax       time.Time // the end of the x axis.  Only used if start is set.
+	days       int       // 0 if start is set from days before now.
+	showVisual bool
+	agg        aggQ
+	qc         []string
+	o          outlierQ
+	corrected  bool
+	scheme     string
+}
+
+/*
+getPlotQ returns the plotQ for the query parameters.  The time range is the days before now,
+days from start if both are set, or start to now.
+*/
+func getPlotQ(v url.Values) (plotQ, *weft.Result) {
+	var q plotQ
+	var res *weft.Result
+
+	if q.plotType, res = getPlotType(v); !res.Ok {
+		return q, res
+	}
+
+	if q.format, res = getFormat(v); !res.Ok {
+		return q, res
+	}
+
+	if q.layout, res = getLayout(v); !res.Ok {
+		return q, res
+	}
+
+	if q.showVisual, res = getShowVisual(v); !res.Ok {
+		return q, res
+	}
+
+	if q.start, res = getStart(v); !res.Ok {
+		return q, res
+	}
+
+	if q.days, res = getDays(v); !res.Ok {
+		return q, res
+	}
+
+	if q.agg, res = getAggregate(v); !res.Ok {
+		return q, res
+	}
+
+	if q.qc, res = getQC(v); !res.Ok {
+		return q, res
+	}
+
+	if q.corrected, res = getCorrected(v); !res.Ok {
+		return q, res
+	}
+
+	if q.o, res = getHighlight(v); !res.Ok {
+		return q, res
+	}
+
+	q.scheme = v.Get("scheme")
+
+	switch {
+	case q.start.IsZero() && q.days > 0:
+		q.xMax = time.Now().UTC()
+		q.start = q.xMax.Add(time.Duration(q.days*-1) * time.Hour * 24)
+		q.days = 0 // add all data > than start by setting 0.  Allows for adding start end to URL.
+	case !q.start.IsZero() && q.days > 0:
+		q.end = q.start.Add(time.Duration(q.days*1) * time.Hour * 24)
+		q.xMax = q.end
+	case !q.start.IsZero() && q.days == 0:
+		q.xMax = time.Now().UTC()
+	}
+
+	return q, &weft.StatusOK
+}
+
+// obsQ returns an obsQ for typeID in unit u over the time range for q.
+func (q plotQ) obsQ(typeID string, u unitQ) obsQ {
+	return obsQ{
+		typeID:    typeID,
+		start:     q.start,
+		end:       q.end,
+		qc:        q.qc,
+		corrected: q.corrected,
+		unit:      u,
+	}
+}
+
+// setXAxis sets the x axis on p if the time range for q has a start.
+func (q plotQ) setXAxis(p *plt) {
+	if !q.start.IsZero() {
+		p.SetXAxis(q.start, q.xMax)
+	}
+}
+
+// addEvents adds the equipment epochs, and visual observations if asked for, at s to p.
+func (q plotQ) addEvents(p *plt, s siteQ) error {
+	if err := p.addEpochEvents(s, q.start, q.end); err != nil {
+		return err
+	}
+
+	if q.showVisual {
+		return p.addVisualEvents(s, q.start, q.days)
+	}
+
+	return nil
+}
+
+// draw draws p to b in the plot type and format for q.
+func (q plotQ) draw(p plt, h http.Header, b *bytes.Buffer) *weft.Result {
+	if q.scheme != "" {
+		p.SetScheme(q.scheme)
+	}
+
+	var d plotter
+
+	switch q.plotType {
+	case ``, `line`:
+		d = &ts.Line
+	case `scatter`:
+		d = &ts.Scatter
+	}
+
+	return writePlot(d, p.Plot, q.layout, q.format, h, b)
+}
+
 func plotSite(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"siteID", "typeID", "networkID"}, []string{"days", "yrange", "type", "start", "stddev", "showMethod", "scheme"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"siteID", "typeID", "networkID"}, []string{"days", "yrange", "type", "start", "stddev", "showMethod", "showVisual", "scheme", "interval", "aggregate", "qc", "highlight", "outliers", "threshold", "window", "corrected", "unit", "format", "width", "height", "yscale"}); !res.Ok {
 		return res
 	}
 
@@ -23,17 +157,17 @@ func plotSite(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 
 	v := r.URL.Query()
 
-	var plotType string
+	var pq plotQ
 	var s siteQ
 	var t typeQ
-	var start time.Time
-	var days int
 	var ymin, ymax float64
+	var logY bool
 	var showMethod bool
 	var stddev string
+	var u unitQ
 	var res *weft.Result
 
-	if plotType, res = getPlotType(v); !res.Ok {
+	if pq, res = getPlotQ(v); !res.Ok {
 		return res
 	}
 
@@ -41,19 +175,19 @@ func plotSite(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return res
 	}
 
-	if stddev, res = getStddev(v); !res.Ok {
-		return res
+	if pq.agg.on() && showMethod {
+		return weft.BadRequest("showMethod can not be used with interval.")
 	}
 
-	if start, res = getStart(v); !res.Ok {
+	if stddev, res = getStddev(v); !res.Ok {
 		return res
 	}
 
-	if days, res = getDays(v); !res.Ok {
+	if ymin, ymax, res = getYRange(v); !res.Ok {
 		return res
 	}
 
-	if ymin, ymax, res = getYRange(v); !res.Ok {
+	if logY, res = getYScale(v, ymin, ymax); !res.Ok {
 		return res
 	}
 
@@ -65,19 +199,13 @@ func plotSite(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return res
 	}
 
+	if u, res = getUnit(v, t.typeID, t.unit); !res.Ok {
+		return res
+	}
+
 	var p plt
 
-	switch {
-	case start.IsZero() && days > 0:
-		n := time.Now().UTC()
-		start = n.Add(time.Duration(days*-1) * time.Hour * 24)
-		p.SetXAxis(start, n)
-		days = 0 // add all data > than start by setting 0.  Allows for adding start end to URL.
-	case !start.IsZero() && days > 0:
-		p.SetXAxis(start, start.Add(time.Duration(days*1)*time.Hour*24))
-	case !start.IsZero() && days == 0:
-		p.SetXAxis(start, time.Now().UTC())
-	}
+	pq.setXAxis(&p)
 
 	switch {
 	case ymin == 0 && ymax == 0:
@@ -87,156 +215,119 @@ func plotSite(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		p.SetYAxis(ymin, ymax)
 	}
 
+	if logY {
+		p.SetYLog()
+	}
+
 	p.SetTitle(fmt.Sprintf("%s (%s) - %s", s.siteID, s.name, t.description))
-	p.SetUnit(t.unit)
-	p.SetYLabel(fmt.Sprintf("%s (%s)", t.name, t.unit))
+	p.SetUnit(u.symbol)
+	p.SetYLabel(fmt.Sprintf("%s (%s)", t.name, u.symbol))
 
 	var err error
 
+	q := pq.obsQ(t.typeID, u)
+
 	switch showMethod {
 	case false:
-		err = p.addSeries(t, start, days, s)
+		err = p.addSeries(q, pq.agg, pq.o, s)
 	case true:
-		err = p.addSeriesLabelMethod(t, start, days, s)
+		err = p.addSeriesLabelMethod(q, pq.o, s)
 	}
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
 	if stddev == `pop` {
-		err = p.setStddevPop(s, t, start, days)
+		err = p.setStddevPop(s, q)
 	}
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
-	if v.Get("scheme") != "" {
-		p.SetScheme(v.Get("scheme"))
-	}
-
-	switch plotType {
-	case ``, `line`:
-		err = ts.Line.Draw(p.Plot, b)
-	case `scatter`:
-		err = ts.Scatter.Draw(p.Plot, b)
-	}
-	if err != nil {
+	if err = pq.addEvents(&p, s); err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
-	return &weft.StatusOK
+	return pq.draw(p, h, b)
 }
 
 /*
-to add all data leave start and days 0
-to add all data after start set start != 0 and days == 0
-to add n days of data after start set start != 0 and days != 0
+addSeries adds a series for each site with the observations matching q.  The site in q is
+set from each site.  The observations are aggregated by agg.
+Observations flagged bad are drawn hollow.  Outliers found in each series with o are highlighted.
+to add all data leave q.start and q.end zero
+to add all data after start set q.start != 0 and q.end zero
+to add data between start and end set q.start != 0 and q.end != 0
 */
-func (plt *plt) addSeries(t typeQ, start time.Time, days int, sites ...siteQ) (err error) {
+func (plt *plt) addSeries(q obsQ, agg aggQ, o outlierQ, sites ...siteQ) error {
 	for _, s := range sites {
-		var rows *sql.Rows
-
-		switch {
-		case start.IsZero() && days == 0:
-			rows, err = db.Query(
-				`SELECT time, value, error FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID)
-		case !start.IsZero() && days == 0:
-			rows, err = db.Query(`SELECT time, value, error FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	AND time > $4
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID, start)
-		case !start.IsZero() && days != 0:
-			rows, err = db.Query(`SELECT time, value, error FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	AND time > $4
-	AND time < $5
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID, start, start.Add(time.Duration(days*1)*time.Hour*24))
-		}
+		ser, err := querySeries(q, agg, o, s)
 		if err != nil {
-			return
+			return err
 		}
-		defer rows.Close()
 
-		var ser ts.Series
-		ser.Label = fmt.Sprintf("%s.%s", s.networkID, s.siteID)
+		plt.AddSeries(ser)
+	}
+	return nil
+}
+
+/*
+querySeries returns a series for the site s with the observations matching q.  The site in q is
+set from s.  The series is labelled with the site.  The observations are aggregated by agg.
+Observations flagged bad are drawn hollow.  Outliers found with o are highlighted.
+*/
+func querySeries(q obsQ, agg aggQ, o outlierQ, s siteQ) (ser ts.Series, err error) {
+	q.networkID = s.networkID
+	q.siteID = s.siteID
+
+	where, args := q.where()
+
+	var rows *sql.Rows
+
+	switch agg.on() {
+	case true:
+		rows, err = db.Query(`SELECT time, value, error, '' FROM `+agg.from(q.table(), where)+`
+		ORDER BY time ASC;`, args...)
+	case false:
+		rows, err = db.Query(`SELECT time, value, error, qc FROM `+q.table()+`
+		`+where+`
+		ORDER BY time ASC;`, args...)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
 
-		for rows.Next() {
-			p := ts.Point{}
-			err = rows.Scan(&p.DateTime, &p.Value, &p.Error)
-			if err != nil {
-				return
-			}
+	ser.Label = fmt.Sprintf("%s.%s", s.networkID, s.siteID)
 
-			ser.Points = append(ser.Points, p)
+	for rows.Next() {
+		p := ts.Point{}
+		var f string
+		err = rows.Scan(&p.DateTime, &p.Value, &p.Error, &f)
+		if err != nil {
+			return
 		}
-		rows.Close()
+		p.Hollow = f == "bad"
 
-		plt.AddSeries(ser)
+		ser.Points = append(ser.Points, p)
 	}
+
+	highlightOutliers(ser.Points, o)
+
 	return
 }
 
-func (plt *plt) addSeriesLabelMethod(t typeQ, start time.Time, days int, s siteQ) (err error) {
+func (plt *plt) addSeriesLabelMethod(q obsQ, o outlierQ, s siteQ) (err error) {
+	q.networkID = s.networkID
+	q.siteID = s.siteID
+
+	where, args := q.where()
+
 	var rows *sql.Rows
 
-	switch {
-	case start.IsZero() && days == 0:
-		rows, err = db.Query(
-			`SELECT time, value, error, methodpk FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID)
-	case !start.IsZero() && days == 0:
-		rows, err = db.Query(
-			`SELECT time, value, error, methodpk FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	AND time > $4
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID, start)
-	case !start.IsZero() && days != 0:
-		rows, err = db.Query(
-			`SELECT time, value, error, methodpk FROM fits.observation
-		WHERE
-		sitepk = (
-			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
-			)
-	AND typepk = (
-		SELECT typepk FROM fits.type WHERE typeid = $3
-		)
-	AND time > $4
-	AND time < $5
-	ORDER BY time ASC;`, s.networkID, s.siteID, t.typeID, start, start.Add(time.Duration(days*1)*time.Hour*24))
-	}
+	rows, err = db.Query(`SELECT time, value, error, qc, methodpk FROM `+q.table()+`
+		`+where+`
+		ORDER BY time ASC;`, args...)
 	if err != nil {
 		return
 	}
@@ -244,13 +335,15 @@ func (plt *plt) addSeriesLabelMethod(t typeQ, start time.Time, days int, s siteQ
 
 	series := make(map[int][]ts.Point)
 	var methodPK int
+	var f string
 
 	for rows.Next() {
 		p := ts.Point{}
-		err = rows.Scan(&p.DateTime, &p.Value, &p.Error, &methodPK)
+		err = rows.Scan(&p.DateTime, &p.Value, &p.Error, &f, &methodPK)
 		if err != nil {
 			return
 		}
+		p.Hollow = f == "bad"
 		series[methodPK] = append(series[methodPK], p)
 	}
 	rows.Close()
@@ -264,31 +357,24 @@ func (plt *plt) addSeriesLabelMethod(t typeQ, start time.Time, days int, s siteQ
 			return
 		}
 
+		highlightOutliers(v, o)
+
 		plt.AddSeries(ts.Series{Label: m, Points: v})
 	}
 
 	return
 }
 
-func (plt *plt) setStddevPop(s siteQ, t typeQ, start time.Time, days int) (err error) {
+// setStddevPop sets the mean and population stddev on the plot for the observations for s matching q.
+// The qc flags in q are not used.
+func (plt *plt) setStddevPop(s siteQ, q obsQ) (err error) {
 	var m, d float64
-	switch {
-	case start.IsZero() && days == 0:
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-         WHERE
-         sitepk = (SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1)
-         AND typepk = ( SELECT typepk FROM fits.type WHERE typeid = $3 )`,
-			s.networkID, s.siteID, t.typeID).Scan(&m, &d)
-	case !start.IsZero() && days == 0:
-		err = db.QueryRow(
-			`SELECT avg(value), stddev_pop(value) FROM fits.observation
-          WHERE
-          sitepk = (SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1)
-	      AND typepk = (SELECT typepk FROM fits.type WHERE typeid = $3 )
-	      AND time > $4`,
-			s.networkID, s.siteID, t.typeID, start).Scan(&m, &d)
-	}
+
+	q.networkID = s.networkID
+	q.siteID = s.siteID
+	q.qc = nil
+
+	m, d, err = stddevPop(q)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/GeoNet/weft"
+	"net/http"
+)
+
+/*
+plotSiteTypes plots two types for a site.  The first type is on the left y axis and the second
+is on the right y axis.  Each axis is scaled on its own data in the unit for the type.  yscale=log
+applies to both axes.  The options for a single y axis (yrange, stddev, showMethod, and unit) are not allowed.
+*/
+func plotSiteTypes(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"siteID", "typeID", "networkID"}, []string{"days", "type", "start", "showVisual", "scheme", "interval", "aggregate", "qc", "highlight", "outliers", "threshold", "window", "corrected", "format", "width", "height", "yscale"}); !res.Ok {
+		return res
+	}
+
+	h.Set("Content-Type", "image/svg+xml")
+
+	v := r.URL.Query()
+
+	var pq plotQ
+	var s siteQ
+	var t []typeQ
+	var logY bool
+	var res *weft.Result
+
+	if pq, res = getPlotQ(v); !res.Ok {
+		return res
+	}
+
+	if logY, res = getYScale(v, 0, 0); !res.Ok {
+		return res
+	}
+
+	if t, res = getTypes(v); !res.Ok {
+		return res
+	}
+
+	if s, res = getSite(v); !res.Ok {
+		return res
+	}
+
+	var p plt
+
+	pq.setXAxis(&p)
+
+	if logY {
+		p.SetYLog()
+	}
+
+	p.SetTitle(fmt.Sprintf("%s (%s) - %s and %s", s.siteID, s.name, t[0].description, t[1].description))
+	p.SetUnit(t[0].unit)
+	p.SetYLabel(fmt.Sprintf("%s (%s)", t[0].name, t[0].unit))
+	p.SetRightUnit(t[1].unit)
+	p.SetRightYLabel(fmt.Sprintf("%s (%s)", t[1].name, t[1].unit))
+
+	for i := range t {
+		ser, err := querySeries(pq.obsQ(t[i].typeID, unitQ{symbol: t[i].unit}), pq.agg, pq.o, s)
+		if err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		// label by type so the key shows which axis each type is on.
+		ser.Label = t[i].typeID
+
+		switch i {
+		case 0:
+			p.AddSeries(ser)
+		default:
+			p.AddRightSeries(ser)
+		}
+	}
+
+	if err := pq.addEvents(&p, s); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return pq.draw(p, h, b)
+}
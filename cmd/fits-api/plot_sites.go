@@ -3,14 +3,12 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"github.com/GeoNet/fits/internal/ts"
 	"github.com/GeoNet/weft"
 	"net/http"
-	"time"
 )
 
 func plotSites(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"sites", "typeID"}, []string{"days", "yrange", "type", "start", "scheme"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"sites", "typeID"}, []string{"days", "yrange", "type", "start", "scheme", "interval", "aggregate", "qc", "unit", "format", "width", "height", "yscale"}); !res.Ok {
 		return res
 	}
 
@@ -18,51 +16,41 @@ func plotSites(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 
 	v := r.URL.Query()
 
-	var plotType string
+	var pq plotQ
 	var s []siteQ
 	var t typeQ
-	var start time.Time
-	var days int
 	var ymin, ymax float64
+	var logY bool
+	var u unitQ
 	var res *weft.Result
 
-	if plotType, res = getPlotType(v); !res.Ok {
+	if pq, res = getPlotQ(v); !res.Ok {
 		return res
 	}
 
-	if start, res = getStart(v); !res.Ok {
+	if ymin, ymax, res = getYRange(v); !res.Ok {
 		return res
 	}
 
-	if days, res = getDays(v); !res.Ok {
+	if logY, res = getYScale(v, ymin, ymax); !res.Ok {
 		return res
 	}
 
-	if ymin, ymax, res = getYRange(v); !res.Ok {
+	if t, res = getType(v); !res.Ok {
 		return res
 	}
 
-	if t, res = getType(v); !res.Ok {
+	if s, res = getSites(v); !res.Ok {
 		return res
 	}
 
-	if s, res = getSites(v); !res.Ok {
+	if u, res = getUnit(v, t.typeID, t.unit); !res.Ok {
 		return res
 	}
 
 	var p plt
 
-	switch {
-	case start.IsZero() && days > 0:
-		n := time.Now().UTC()
-		start = n.Add(time.Duration(days*-1) * time.Hour * 24)
-		p.SetXAxis(start, n)
-		days = 0 // add all data > than start by setting 0.  Allows for adding start end to URL.
-	case !start.IsZero() && days > 0:
-		p.SetXAxis(start, start.Add(time.Duration(days*1)*time.Hour*24))
-	case !start.IsZero() && days == 0:
-		p.SetXAxis(start, time.Now().UTC())
-	}
+	pq.setXAxis(&p)
 
 	switch {
 	case ymin == 0 && ymax == 0:
@@ -72,30 +60,17 @@ func plotSites(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		p.SetYAxis(ymin, ymax)
 	}
 
-	p.SetTitle(fmt.Sprintf("%s", t.description))
-	p.SetUnit(t.unit)
-	p.SetYLabel(fmt.Sprintf("%s (%s)", t.name, t.unit))
-
-	var err error
-
-	err = p.addSeries(t, start, days, s...)
-	if err != nil {
-		return weft.ServiceUnavailableError(err)
+	if logY {
+		p.SetYLog()
 	}
 
-	if v.Get("scheme") != "" {
-		p.SetScheme(v.Get("scheme"))
-	}
+	p.SetTitle(fmt.Sprintf("%s", t.description))
+	p.SetUnit(u.symbol)
+	p.SetYLabel(fmt.Sprintf("%s (%s)", t.name, u.symbol))
 
-	switch plotType {
-	case ``, `line`:
-		err = ts.Line.Draw(p.Plot, b)
-	case `scatter`:
-		err = ts.Scatter.Draw(p.Plot, b)
-	}
-	if err != nil {
+	if err := p.addSeries(pq.obsQ(t.typeID, u), pq.agg, outlierQ{}, s...); err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
-	return &weft.StatusOK
+	return pq.draw(p, h, b)
 }
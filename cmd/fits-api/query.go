@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+obsQ is for building queries on fits.observation for a single site and type.
+Fields that are left as zero values do not restrict the query.  If networkID
+is empty the query is for siteID in any network.
+*/
+type obsQ struct {
+	networkID, siteID, typeID string
+	methodID                  string
+	systemID, sampleID        string    // sampleID must be used with systemID.
+	start                     time.Time // observations after start.
+	end                       time.Time // observations before end.
+	asOf                      time.Time // the versions of observations that were current at asOf.  Use with table().
+	qc                        []string  // observations with any of these qc flags.
+	corrected                 bool      // apply the offsets for site equipment epochs to values.  Use with table().
+	unit                      unitQ     // convert values and errors to this unit.  Use with table().
+}
+
+// revisions is the current and previous versions of observations with the times each version was current.
+const revisions = `(SELECT sitepk, typepk, methodpk, samplepk, time, value, error, qc,
+		updated AS published, updated_by AS published_by, NULL::timestamptz AS replaced, '' AS replaced_by
+		FROM fits.observation
+		UNION ALL
+		SELECT sitepk, typepk, methodpk, samplepk, time, value, error, qc, published, published_by, replaced, replaced_by
+		FROM fits.observation_revision) AS observation`
+
+// corrected subtracts the offsets for all site equipment epochs at or before each observation from the value.
+// The first %s is any extra columns to select and the second the source of the observations.
+const corrected = `(SELECT sitepk, typepk, methodpk, samplepk, time, error, qc%s,
+		value - COALESCE((SELECT sum(value_offset) FROM fits.site_epoch join fits.epoch_offset using (epochpk)
+			WHERE site_epoch.sitepk = observation.sitepk AND epoch_offset.typepk = observation.typepk
+			AND site_epoch.time <= observation.time), 0) AS value
+		FROM %s) AS observation`
+
+// table returns the SQL source of observations for q.  If asOf is set this includes previous versions
+// of observations and where() selects the version current at asOf.  If corrected is set the values
+// have the offsets for site equipment epochs applied.  Values are then converted to unit.
+func (q obsQ) table() string {
+	t := `fits.observation`
+	var cols string
+
+	if !q.asOf.IsZero() {
+		t = revisions
+		cols = `, published, replaced`
+	}
+
+	if q.corrected {
+		t = fmt.Sprintf(corrected, cols, t)
+	}
+
+	if q.unit.on() {
+		t = fmt.Sprintf(converted, cols, q.unit.factor, q.unit.offset, q.unit.factor, t)
+	}
+
+	return t
+}
+
+// where returns an SQL WHERE clause for q and the arguments for the clause.
+func (q obsQ) where() (string, []interface{}) {
+	var args []interface{}
+	var w string
+
+	switch q.networkID {
+	case "":
+		args = []interface{}{q.siteID, q.typeID}
+		w = `WHERE
+		sitepk IN (
+			SELECT sitepk from fits.site where siteid = $1
+			)
+	AND typepk = (
+		SELECT typepk FROM fits.type WHERE typeid = $2
+		)`
+	default:
+		args = []interface{}{q.networkID, q.siteID, q.typeID}
+		w = `WHERE
+		sitepk = (
+			SELECT DISTINCT ON (sitepk) sitepk from fits.site join fits.network using (networkpk) where siteid = $2 and networkid = $1
+			)
+	AND typepk = (
+		SELECT typepk FROM fits.type WHERE typeid = $3
+		)`
+	}
+
+	if q.methodID != "" {
+		args = append(args, q.methodID)
+		w += fmt.Sprintf(`
+	AND methodpk = (
+		SELECT methodpk FROM fits.method WHERE methodid = $%d
+		)`, len(args))
+	}
+
+	switch {
+	case q.sampleID != "":
+		args = append(args, q.systemID, q.sampleID)
+		w += fmt.Sprintf(`
+	AND samplepk = (
+		SELECT samplepk FROM fits.sample join fits.system using (systempk) WHERE systemid = $%d AND sampleid = $%d
+		)`, len(args)-1, len(args))
+	case q.systemID != "":
+		args = append(args, q.systemID)
+		w += fmt.Sprintf(`
+	AND samplepk IN (
+		SELECT samplepk FROM fits.sample join fits.system using (systempk) WHERE systemid = $%d
+		)`, len(args))
+	}
+
+	if !q.start.IsZero() {
+		args = append(args, q.start)
+		w += fmt.Sprintf(`
+	AND time > $%d`, len(args))
+	}
+
+	if !q.end.IsZero() {
+		args = append(args, q.end)
+		w += fmt.Sprintf(`
+	AND time < $%d`, len(args))
+	}
+
+	if len(q.qc) > 0 {
+		var in []string
+		for _, f := range q.qc {
+			args = append(args, f)
+			in = append(in, fmt.Sprintf("$%d", len(args)))
+		}
+		w += `
+	AND qc IN (` + strings.Join(in, ", ") + `)`
+	}
+
+	if !q.asOf.IsZero() {
+		args = append(args, q.asOf)
+		w += fmt.Sprintf(`
+	AND published <= $%d AND (replaced IS NULL OR replaced > $%d)`, len(args), len(args))
+	}
+
+	return w, args
+}
@@ -5,20 +5,34 @@ import (
 	"github.com/GeoNet/weft"
 	"log"
 	"net/http"
+	"strings"
 )
 
 var mux = http.NewServeMux()
 
+// writeMux routes requests that change data in the DB.  All routes must use makeHandlerWrite.
+var writeMux = http.NewServeMux()
+
 func init() {
 	mux.HandleFunc("/spark", weft.MakeHandlerAPI(spark))
 	mux.HandleFunc("/map/site", weft.MakeHandlerAPI(siteMapHandler))
 	mux.HandleFunc("/observation_results", weft.MakeHandlerAPI(observationResults))
 	mux.HandleFunc("/observation/stats", weft.MakeHandlerAPI(observationStats))
+	mux.HandleFunc("/observation/trend", weft.MakeHandlerAPI(observationTrend))
+	mux.HandleFunc("/observation/latest", weft.MakeHandlerAPI(observationLatest))
+	mux.HandleFunc("/observation/changes", weft.MakeHandlerAPI(observationChanges))
+	mux.HandleFunc("/observation/history", weft.MakeHandlerAPI(observationHistory))
+	mux.HandleFunc("/availability", weft.MakeHandlerAPI(observationAvailability))
 	mux.HandleFunc("/type", weft.MakeHandlerAPI(types))
 	mux.HandleFunc("/method", weft.MakeHandlerAPI(method))
+	mux.HandleFunc("/system", weft.MakeHandlerAPI(system))
+	mux.HandleFunc("/sample", weft.MakeHandlerAPI(sample))
+	mux.HandleFunc("/visual_observation", weft.MakeHandlerAPI(visualObservation))
 	mux.HandleFunc("/plot", weft.MakeHandlerAPI(plotHandler))
 	mux.HandleFunc("/observation", weft.MakeHandlerAPI(observationHandler))
 	mux.HandleFunc("/site", weft.MakeHandlerAPI(siteHandler))
+	mux.HandleFunc("/site/epoch", weft.MakeHandlerAPI(siteEpochs))
+	mux.HandleFunc("/site/history", weft.MakeHandlerAPI(siteHistory))
 	mux.HandleFunc("/", weft.MakeHandlerPage(charts))
 	mux.HandleFunc("/charts", weft.MakeHandlerPage(charts))
 	mux.Handle("/js/", http.StripPrefix("/js/", http.FileServer(http.Dir("assets/js"))))
@@ -31,21 +45,38 @@ func init() {
 	// routes for balancers and probes.
 	mux.HandleFunc("/soh/up", http.HandlerFunc(up))
 	mux.HandleFunc("/soh", http.HandlerFunc(soh))
+
+	writeMux.HandleFunc("/observation", makeHandlerWrite(observationWriteHandler))
+	writeMux.HandleFunc("/observation/qc", makeHandlerWrite(qcWriteHandler))
+	writeMux.HandleFunc("/site", makeHandlerWrite(siteWriteHandler))
+	writeMux.HandleFunc("/site/epoch", makeHandlerWrite(epochWriteHandler))
+	writeMux.HandleFunc("/unit", makeHandlerWrite(unitWriteHandler))
+	writeMux.HandleFunc("/type", makeHandlerWrite(typeWriteHandler))
+	writeMux.HandleFunc("/method", makeHandlerWrite(methodWriteHandler))
+	writeMux.HandleFunc("/type_method", makeHandlerWrite(typeMethodWriteHandler))
+	writeMux.HandleFunc("/visual_observation", makeHandlerWrite(visualWriteHandler))
+	writeMux.HandleFunc("/", http.HandlerFunc(methodNotAllowed))
 }
 
-func inbound(h http.Handler) http.Handler {
+// inbound sends GET requests to h and requests that change data to hw.
+func inbound(h, hw http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case "GET":
 			h.ServeHTTP(w, r)
+		case "POST", "PUT", "DELETE":
+			hw.ServeHTTP(w, r)
 		default:
-			weft.Write(w, r, &weft.MethodNotAllowed)
-			weft.MethodNotAllowed.Count()
-			return
+			methodNotAllowed(w, r)
 		}
 	})
 }
 
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	weft.Write(w, r, &weft.MethodNotAllowed)
+	weft.MethodNotAllowed.Count()
+}
+
 // these handlers take care of the extra routing based on optional query parameters
 
 func observationHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
@@ -56,6 +87,97 @@ func observationHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.R
 	}
 }
 
+func observationWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return observationAdd(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func qcWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "PUT":
+		return observationQC(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func siteWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return siteCreate(r, h, b)
+	case "PUT":
+		return siteUpdate(r, h, b)
+	case "DELETE":
+		return siteDecommission(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func epochWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return epochAdd(r, h, b)
+	case "DELETE":
+		return epochDelete(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func unitWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return unitAdd(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func typeWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return typeAdd(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func methodWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return methodAdd(r, h, b)
+	case "DELETE":
+		return methodDelete(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func typeMethodWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "PUT":
+		return typeMethodLink(r, h, b)
+	case "DELETE":
+		return typeMethodUnlink(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
+func visualWriteHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	switch r.Method {
+	case "POST":
+		return visualAdd(r, h, b)
+	default:
+		return &weft.MethodNotAllowed
+	}
+}
+
 func siteMapHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	v := r.URL.Query()
 
@@ -70,9 +192,12 @@ func siteMapHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Resul
 }
 
 func plotHandler(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if r.URL.Query().Get("siteID") != "" {
+	switch {
+	case r.URL.Query().Get("siteID") != "" && strings.Contains(r.URL.Query().Get("typeID"), ","):
+		return plotSiteTypes(r, h, b)
+	case r.URL.Query().Get("siteID") != "":
 		return plotSite(r, h, b)
-	} else {
+	default:
 		return plotSites(r, h, b)
 	}
 }
@@ -20,28 +20,218 @@ var routes = wt.Requests{
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&days=400"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&methodID=m1"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&unit=mm"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&within=POLYGON((170.18+-37.52,177.19+-47.52,177.20+-37.53,170.18+-37.52))"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&within=POLYGON((170.18+-37.52,177.19+-47.52,177.20+-37.53,170.18+-37.52))&methodID=m1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&methodID=m1&days=400"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&showSample=true"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&srsName=EPSG:27200"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&unit=mm"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&within=POLYGON((170.18+-37.52,177.19+-47.52,177.20+-37.53,170.18+-37.52))&methodID=m1"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-07T00:00:00Z&end=2000-01-09T00:00:00Z"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-07T00:00:00Z&days=2"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&end=2000-01-09T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&end=2000-01-09T00:00:00Z&days=2"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&methodID=m1&start=2000-01-07T00:00:00Z&end=2000-01-10T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&percentiles=10,50,90"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=mad"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=mad&threshold=2&window=3"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=sigma&threshold=1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&start=1990-01-01T00:00:00Z&end=1990-02-01T00:00:00Z"}, // no observations
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-07T00:00:00Z&end=2000-01-10T00:00:00Z"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1d"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1w&aggregate=median"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1M&aggregate=count"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=12h&aggregate=max"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&interval=1y&aggregate=last&methodID=m1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1&interval=1d&aggregate=min"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1,TEST2"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1,TEST2&interval=6h&aggregate=first"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1,TEST2&interval=2M"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation_results?typeID=t1&siteID=TEST1'+OR+'1'='1"}, // site IDs are bind parameters
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&interval=2d&aggregate=mean"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&interval=1w&aggregate=max"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/trend?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/trend?typeID=t1&siteID=TEST1&networkID=TN1&breaks=2000-01-08T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/trend?typeID=t1&siteID=TEST1&networkID=TN1&methodID=m1&end=2000-01-10T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1&cadence=1d&start=2000-01-01T00:00:00Z&end=2000-02-01T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1&start=1990-01-01T00:00:00Z&days=10"}, // no observations
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/availability?typeID=t1&siteID=TEST2&networkID=TN1&methodID=m1&cadence=12h&start=2000-01-01T00:00:00Z"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation/latest?typeID=t1"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation/latest?typeID=t1&methodID=m1"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/observation/latest?typeID=t1&methodID=m1&within=POLYGON((170.18+-37.52,177.19+-47.52,177.20+-37.53,170.18+-37.52))"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/changes?since=2000-01-01T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/changes?since=2000-01-01T00:00:00Z&limit=2&cursor=1-2"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/history?typeID=t1&siteID=TEST1&networkID=TN1&time=2000-01-09T12:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/history?typeID=t1&siteID=TEST2&networkID=TN1&time=2000-01-08T12:00:00Z&methodID=m2"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&qc=good,unverified"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&showQC=true"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&showSample=true&showQC=true"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&qc=bad"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&asOf=2000-01-01T00:00:00Z"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&asOf=2100-01-01T00:00:00Z&interval=1d"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&asOf=2100-01-01T00:00:00Z&showSample=true"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&corrected=true"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&unit=mm"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&unit=mm&corrected=true&interval=1d"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&unit=mm"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&corrected=true&asOf=2100-01-01T00:00:00Z&interval=1d"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/site/epoch?siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/site/history?siteID=TEST1&networkID=TN1"},
 	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/type"},
 	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/method?typeID=t1"},
 	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/method"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/system"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/sample"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/sample?systemID=lab"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&showSample=true"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&systemID=lab"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&systemID=lab&sampleID=0001&methodID=m1&showSample=true"},
 	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&type=scatter"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&qc=good,unverified"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&highlight=outliers"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&highlight=outliers&outliers=sigma&threshold=1&type=scatter"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&highlight=outliers&window=3&showMethod=true"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&showMethod=true&qc=bad"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&corrected=true"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t2&siteID=TEST2&networkID=TN1&unit=%C2%B0C"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&unit=mm"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&unit=mm&stddev=pop"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&corrected=true&showMethod=true&stddev=pop"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&qc=unverified"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&qc=unverified,suspect"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&format=png"},
+	{ID: wt.L(), Accept: pdf, Content: pdf, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&format=pdf&type=scatter"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&format=png"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&format=svg"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&format=png"},
+	{ID: wt.L(), Accept: pdf, Content: pdf, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&format=pdf"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&format=png&type=scatter&label=latest"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/map/site?siteID=TEST1&networkID=TN1&format=png"},
+	{ID: wt.L(), Accept: pdf, Content: pdf, URL: "/map/site?siteID=TEST1&networkID=TN1&format=pdf"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/map/site?sites=TN1.TEST1,TN1.TEST2&width=300&insetBbox=NewZealand&format=png"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&width=400&height=200"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&width=1200"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&width=1200&height=400&format=png"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&label=none&width=300&height=60"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log&yrange=0.1,100&stddev=pop"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&yscale=log"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=linear"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t2,t1&siteID=TEST2&networkID=TN1&type=scatter&highlight=outliers"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&start=2000-01-01T00:00:00Z&days=30&showVisual=true&corrected=true"},
+	{ID: wt.L(), Accept: png, Content: png, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&format=png&width=1200"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&yscale=log"},
 	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yrange=12.2"},
 	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&days=10000"},
 	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&days=10000&yrange=12.2"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&showVisual=true"},
+	{ID: wt.L(), Accept: svg, Content: svg, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-01T00:00:00Z&days=30&showVisual=true"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/visual_observation?networkID=TN1&siteID=TEST1"},
+	{ID: wt.L(), Accept: v1JSON, Content: v1JSON, URL: "/visual_observation?networkID=TN1&siteID=TEST1&start=2000-01-07T12:00:00Z&days=2"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/visual_observation?networkID=TN1&siteID=TEST1"},
+	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, URL: "/visual_observation?networkID=TN1&siteID=TEST1&days=30"},
 
 	// Routes that should bad request.
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/history?typeID=t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&qc=dodgy"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&showQC=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&showQC=true&interval=1d"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=iqr"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&threshold=3"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=mad&threshold=-1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=mad&window=2"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&outliers=sigma&window=5"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&highlight=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&outliers=mad"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&qc=good,"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&qc=dodgy"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/history?typeID=t1&siteID=TEST1&networkID=TN1&time=yesterday"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&asOf=yesterday"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&corrected=yes"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&corrected=yes"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&unit=K"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&unit=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&unit=K"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&sites=TN1.TEST1&unit=K"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&unit=K"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/site/epoch?siteID=TEST1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&format=gif"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&sites=TN1.TEST1&format=gif"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&format=gif"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/map/site?siteID=TEST1&networkID=TN1&format=gif"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&width=wide"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&height=-1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&width=20000"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&width=150"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&width=300"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t1&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2,t3&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&stddev=pop"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&yrange=10"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&showMethod=true"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&unit=mm"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&end=2000-01-01T00:00:00Z"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&yscale=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1,t2&siteID=TEST1&networkID=TN1&highlight=bob"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/plot?typeID=t1,notype&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=ln"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log&yrange=12.2"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log&yrange=-1,10"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&sites=TN1.TEST1,TN1.TEST2&yscale=log&yrange=10,1"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&yscale=log&yrange=0,10"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/site/history?siteID=TEST1"},
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1"},
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&days=nan"},
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&days=1000000000000"},
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yrange=-12.2"},
 	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&yrange=0"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&showVisual=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/visual_observation?networkID=TN1&siteID=TEST1&start=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&end=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-09T00:00:00Z&end=2000-01-07T00:00:00Z"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&start=2000-01-07T00:00:00Z&end=2000-01-09T00:00:00Z&days=2"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&days=nan"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&percentiles=101"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/stats?typeID=t1&siteID=TEST1&networkID=TN1&percentiles=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/spark?typeID=t1&siteID=TEST1&networkID=TN1&end=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1x"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=0d"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&aggregate=mean"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1d&aggregate=mode"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST1&networkID=TN1&interval=1d&showSample=true"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation_results?typeID=t1&siteID=TEST1&interval=d"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/plot?typeID=t1&siteID=TEST1&networkID=TN1&interval=1d&showMethod=true"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/trend?typeID=t1&siteID=TEST1&networkID=TN1&breaks=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1&cadence=1M"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/latest?typeID=t1&within=POLYGON((170.18+-37.52,177.19+-47.52))"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/changes?since=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/changes?since=2000-01-01T00:00:00Z&cursor=bob"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/changes?since=2000-01-01T00:00:00Z&cursor=2"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/changes?since=2000-01-01T00:00:00Z&cursor=1--2"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/changes?since=2000-01-01T00:00:00Z&limit=0"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/availability?typeID=t1&siteID=TEST1&networkID=TN1&cadence=-1d"},
+	{ID: wt.L(), Status: http.StatusBadRequest, URL: "/observation/trend?typeID=t1&siteID=TEST1&networkID=TN1&breaks=1999-01-01T00:00:00Z"},
 
 	// CSV routes that should bad request
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&sampleID=0001"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&showSample=bob"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=0"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=8"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&srsName=EPSG:999999"},
+	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&unit=K"},
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&within=POLYGON((177.18+-37.52,177.19+-37.52,177.20+-37.53))"},             // not enough points
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=2&within=POLYGON((177.18+-37.52,177.19+-37.52,177.20+-37.53,178.0+-34.5))"}, // doesn't close
 
@@ -51,8 +241,55 @@ var routes = wt.Requests{
 	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, Status: http.StatusBadRequest, URL: "/site?within=POLYGON((170.18+-37.52,177.19+-47.52))"},                             // not enough points
 	{ID: wt.L(), Accept: v1GeoJSON, Content: v1GeoJSON, Status: http.StatusBadRequest, URL: "/site?within=POLYGON((170.18+-37.52,177.19+-47.52,177.20+-37.53,178.18+-37.52))"}, // doesn't close
 
+	// Routes that change data.  Need credentials.
+	{ID: wt.L(), Method: "POST", Status: http.StatusUnauthorized, URL: "/observation"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "wrong", Status: http.StatusUnauthorized, URL: "/observation"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/observation"}, // no Content-Type
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusMethodNotAllowed, URL: "/observation"},
+	{ID: wt.L(), Method: "PUT", Status: http.StatusUnauthorized, URL: "/observation/qc?networkID=TN1&siteID=TEST1&typeID=t1&start=1990-01-01T00:00:00Z&end=1990-01-02T00:00:00Z&qc=bad"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusMethodNotAllowed, URL: "/observation/qc"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/observation/qc?networkID=TN1&siteID=TEST1&typeID=t1&start=1990-01-01T00:00:00Z&end=1990-01-02T00:00:00Z&qc=dodgy"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/observation/qc?networkID=TN1&siteID=TEST1&typeID=t1&start=1990-01-01T00:00:00Z&qc=bad"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/observation/qc?networkID=TN1&siteID=NOSITE&typeID=t1&start=1990-01-01T00:00:00Z&end=1990-01-02T00:00:00Z&qc=bad"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Content: v1JSON, URL: "/observation/qc?networkID=TN1&siteID=TEST1&typeID=t1&start=1990-01-01T00:00:00Z&end=1990-01-02T00:00:00Z&qc=bad"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusMethodNotAllowed, URL: "/plot"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/site"}, // no Content-Type
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/site"},  // no Content-Type
+	{ID: wt.L(), Method: "DELETE", Status: http.StatusUnauthorized, URL: "/site?networkID=TN1&siteID=TEST1"},
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/site?networkID=TN1&siteID=NOSITE"},
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/site?networkID=TN1&siteID=TEST1&time=bob"},
+	{ID: wt.L(), Method: "POST", Status: http.StatusUnauthorized, URL: "/site/epoch"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusMethodNotAllowed, URL: "/site/epoch"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/site/epoch"}, // no Content-Type
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/site/epoch?networkID=TN1&siteID=TEST1&time=1990-01-01T00:00:00Z"},
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/site/epoch?networkID=TN1&siteID=TEST1&time=bob"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/unit"},   // no Content-Type
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/type"},   // no Content-Type
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/method"}, // no Content-Type
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusConflict, URL: "/method?methodID=m1"},
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusConflict, URL: "/method?methodID=mr"}, // observation revisions only
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/method?methodID=nomethod"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusConflict, URL: "/type_method?typeID=t1&methodID=m1"},
+	{ID: wt.L(), Method: "PUT", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/type_method?typeID=t1&methodID=nomethod"},
+	{ID: wt.L(), Method: "POST", Status: http.StatusUnauthorized, URL: "/visual_observation"},
+	{ID: wt.L(), Method: "POST", User: "test", Password: "test", Status: http.StatusBadRequest, URL: "/visual_observation"}, // no Content-Type
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusConflict, URL: "/type_method?typeID=t1&methodID=m1"},
+	{ID: wt.L(), Method: "DELETE", User: "test", Password: "test", Status: http.StatusNotFound, URL: "/type_method?typeID=t2&methodID=m3"},
+
 	// Routes that should 404
 	{ID: wt.L(), Status: http.StatusNotFound, URL: "/bob"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/sample?systemID=bob"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/observation/latest?typeID=bob"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/observation/history?typeID=t1&siteID=TEST1&networkID=TN1&time=1990-01-01T00:00:00Z"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/site/epoch?siteID=NOSITE&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/site/history?siteID=NOSITE&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/availability?typeID=t1&siteID=NOSITE&networkID=TN1"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/visual_observation?networkID=TN1&siteID=NOSITE"},
+	{ID: wt.L(), Status: http.StatusNotFound, URL: "/observation?typeID=t1&siteID=TEST2&networkID=TN1&systemID=lab&sampleID=bob"},
+	{ID: wt.L(), Accept: v1CSV, Status: http.StatusNotFound, URL: "/observation?typeID=t1&siteID=NOSITE&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Status: http.StatusNotFound, URL: "/observation?typeID=t1&siteID=NOSITE&networkID=TN1"},
+	{ID: wt.L(), Accept: v1JSON, Status: http.StatusNotFound, URL: "/observation?typeID=bob&siteID=TEST1&networkID=TN1"},
+	{ID: wt.L(), Accept: v1GeoJSON, Status: http.StatusNotFound, URL: "/observation?typeID=bob&start=2010-11-24T00:00:00Z&days=2"},
 
 	// CSV routes that should bad request
 	{ID: wt.L(), Accept: v1CSV, Content: v1CSV, Status: http.StatusBadRequest, URL: "/observation?typeID=t1&start=2010-11-24T00:00:00Z&days=0"},
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/GeoNet/weft"
+	"net/http"
+)
+
+func system(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	h.Set("Content-Type", "application/json;version=1")
+
+	var d string
+
+	err := db.QueryRow(
+		`select row_to_json(fc) from (select COALESCE(array_to_json(array_agg(s)), '[]') as system
+		    from (select systemid as "systemID", description
+		    	from fits.system order by systemid) as s) as fc`).Scan(&d)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.WriteString(d)
+
+	return &weft.StatusOK
+}
+
+func sample(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{"systemID"}); !res.Ok {
+		return res
+	}
+
+	h.Set("Content-Type", "application/json;version=1")
+
+	systemID := r.URL.Query().Get("systemID")
+
+	if systemID != "" {
+		if res := validSystem(systemID); !res.Ok {
+			return res
+		}
+	}
+
+	var d string
+	var err error
+
+	switch systemID {
+	case "":
+		err = db.QueryRow(
+			`select row_to_json(fc) from (select COALESCE(array_to_json(array_agg(s)), '[]') as sample
+			    from (select sampleid as "sampleID", systemid as "systemID"
+			    	from fits.sample join fits.system using (systempk) order by systemid, sampleid) as s) as fc`).Scan(&d)
+	default:
+		err = db.QueryRow(
+			`select row_to_json(fc) from (select COALESCE(array_to_json(array_agg(s)), '[]') as sample
+			    from (select sampleid as "sampleID", systemid as "systemID"
+			    	from fits.sample join fits.system using (systempk)
+			    	where systemid = $1 order by sampleid) as s) as fc`, systemID).Scan(&d)
+	}
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.WriteString(d)
+
+	return &weft.StatusOK
+}
+
+// validSystem checks that the systemID exists in the DB.
+func validSystem(systemID string) *weft.Result {
+	var d string
+
+	if err := db.QueryRow("SELECT systemid FROM fits.system WHERE systemid = $1", systemID).Scan(&d); err != nil {
+		if err == sql.ErrNoRows {
+			return &weft.NotFound
+		}
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return &weft.StatusOK
+}
+
+// validSample checks that the sampleID and systemID combination exists in the DB.
+func validSample(systemID, sampleID string) *weft.Result {
+	var d int
+
+	if err := db.QueryRow("SELECT samplepk FROM fits.sample join fits.system using (systempk) WHERE systemid = $1 AND sampleid = $2",
+		systemID, sampleID).Scan(&d); err != nil {
+		if err == sql.ErrNoRows {
+			return &weft.NotFound
+		}
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return &weft.StatusOK
+}
@@ -2,16 +2,16 @@ package main
 
 import (
 	"database/sql"
-	"fmt"
+	"github.com/GeoNet/fits/internal/dbconn"
 	"github.com/GeoNet/map180"
-	_ "github.com/lib/pq"
 	"log"
 	"net/http"
 	"os"
 )
 
 var (
-	db     *sql.DB
+	db     *sql.DB // read only.
+	dbW    *sql.DB // for requests that change data.  Only use from handlers on writeMux.
 	wm     *map180.Map180
 	Prefix string // prefix for logging
 )
@@ -22,6 +22,8 @@ const (
 	v1JSON    = "application/json;version=1"
 	v1CSV     = "text/csv;version=1"
 	svg       = "image/svg+xml"
+	png       = "image/png"
+	pdf       = "application/pdf"
 )
 
 func init() {
@@ -33,14 +35,7 @@ func init() {
 // main connects to the database, sets up request routing, and starts the http server.
 func main() {
 	var err error
-	db, err = sql.Open("postgres",
-		fmt.Sprintf("host=%s connect_timeout=%s user=%s password=%s dbname=%s sslmode=%s",
-			os.Getenv("DB_HOST"),
-			os.Getenv("DB_CONN_TIMEOUT"),
-			os.Getenv("DB_USER"),
-			os.Getenv("DB_PASSWD"),
-			os.Getenv("DB_NAME"),
-			os.Getenv("DB_SSLMODE")))
+	db, err = dbconn.Open(os.Getenv("DB_USER"), os.Getenv("DB_PASSWD"))
 	if err != nil {
 		log.Fatalf("ERROR: problem with DB config: %s", err)
 	}
@@ -54,6 +49,16 @@ func main() {
 		log.Println("Error: problem pinging DB - is it up and contactable?  500s will be served")
 	}
 
+	// requests that change data use a separate user with write permissions.
+	dbW, err = dbconn.Open(os.Getenv("DB_WRITE_USER"), os.Getenv("DB_WRITE_PASSWD"))
+	if err != nil {
+		log.Fatalf("ERROR: problem with DB write config: %s", err)
+	}
+	defer dbW.Close()
+
+	dbW.SetMaxIdleConns(5)
+	dbW.SetMaxOpenConns(5)
+
 	// For map zoom regions other than NZ will need to read some config from somewhere.
 	wm, err = map180.Init(db, map180.Region(`newzealand`), 256000000)
 	if err != nil {
@@ -61,5 +66,5 @@ func main() {
 	}
 
 	log.Print("starting server")
-	log.Fatal(http.ListenAndServe(":8080", inbound(mux)))
+	log.Fatal(http.ListenAndServe(":8080", inbound(mux, writeMux)))
 }
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/GeoNet/fits/internal/dbconn"
 	"log"
 	"net/http/httptest"
 	"os"
@@ -16,13 +14,7 @@ var (
 // setup starts a db connection and test server then inits an http client.
 func setup() {
 	var err error
-	db, err = sql.Open("postgres", fmt.Sprintf("host=%s connect_timeout=%s user=%s password=%s dbname=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_CONN_TIMEOUT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_SSLMODE")))
+	db, err = dbconn.Open(os.Getenv("DB_USER"), os.Getenv("DB_PASSWD"))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -33,7 +25,16 @@ func setup() {
 		log.Fatal(err)
 	}
 
-	testServer = httptest.NewServer(mux)
+	dbW, err = dbconn.Open(os.Getenv("DB_WRITE_USER"), os.Getenv("DB_WRITE_PASSWD"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err = dbW.Ping(); err != nil {
+		log.Fatal(err)
+	}
+
+	testServer = httptest.NewServer(inbound(mux, writeMux))
 
 }
 
@@ -44,4 +45,5 @@ func setup() {
 func teardown() {
 	testServer.Close()
 	db.Close()
+	dbW.Close()
 }
@@ -9,18 +9,21 @@ import (
 )
 
 const (
+	// siteProperties are the GeoJSON properties for a site.
+	siteProperties = `siteid AS "siteID",
+                                height,
+                                ground_relationship AS "groundRelationship",
+                                name,
+                                networkID as "networkID",
+                                to_char(decommissioned, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') as "decommissioned"`
+
 	siteGeoJSON = `SELECT row_to_json(fc)
                          FROM ( SELECT 'FeatureCollection' as type, COALESCE(array_to_json(array_agg(f)), '[]') as features
                          FROM (SELECT 'Feature' as type,
                          ST_AsGeoJSON(s.location)::json as geometry,
                          row_to_json((SELECT l FROM 
                          	(
-                         		SELECT 
-                         		siteid AS "siteID",
-                                height,
-                                ground_relationship AS "groundRelationship",
-                                name,
-                                networkID as "networkID"
+                         		SELECT ` + siteProperties + `
                            ) as l
                          )) as properties FROM (fits.site join fits.network using (networkpk)) as s `
 	fc = ` ) As f )  as fc`
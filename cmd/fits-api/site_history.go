@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"time"
+)
+
+// siteVersions is the current and previous versions of sites with the times each version was valid.
+// A null valid_from is valid from when the site was added and a null valid_to is the current version.
+const siteVersions = `(SELECT sitepk, siteid, networkpk, name, location, height, ground_relationship,
+		valid_from, NULL::timestamptz AS valid_to
+		FROM fits.site
+		UNION ALL
+		SELECT sitepk, siteid, networkpk, site_history.name, site_history.location, site_history.height,
+		site_history.ground_relationship, site_history.valid_from, site_history.valid_to
+		FROM fits.site_history join fits.site using (sitepk)) AS site`
+
+// siteVersion is one version of the attributes of a site.
+type siteVersion struct {
+	Name               string     `json:"name"`
+	Longitude          float64    `json:"longitude"`
+	Latitude           float64    `json:"latitude"`
+	Height             float64    `json:"height"`
+	GroundRelationship float64    `json:"groundRelationship"`
+	ValidFrom          *time.Time `json:"validFrom"`
+	ValidTo            *time.Time `json:"validTo"`
+}
+
+/*
+siteHistory returns the current and previous versions of a site in the order they were valid.
+ValidFrom is null for the first version and ValidTo is null for the current version.
+*/
+func siteHistory(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID"}, []string{}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	if res := validSite(v.Get("networkID"), v.Get("siteID")); !res.Ok {
+		return res
+	}
+
+	rows, err := db.Query(`SELECT name, ST_X(location::geometry), ST_Y(location::geometry), height, ground_relationship,
+		valid_from, valid_to
+		FROM `+siteVersions+` join fits.network using (networkpk)
+		WHERE siteid = $2 AND networkid = $1
+		ORDER BY valid_from ASC NULLS FIRST`, v.Get("networkID"), v.Get("siteID"))
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer rows.Close()
+
+	versions := []siteVersion{}
+
+	for rows.Next() {
+		var s siteVersion
+
+		if err = rows.Scan(&s.Name, &s.Longitude, &s.Latitude, &s.Height, &s.GroundRelationship, &s.ValidFrom, &s.ValidTo); err != nil {
+			return weft.ServiceUnavailableError(err)
+		}
+
+		versions = append(versions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeJSON(versions, h, b)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSiteHistory(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove the site from any earlier test run.
+	for _, q := range []string{
+		`DELETE FROM fits.observation WHERE sitepk IN (SELECT sitepk FROM fits.site WHERE siteid = 'TEST6')`,
+		`DELETE FROM fits.site_history WHERE sitepk IN (SELECT sitepk FROM fits.site WHERE siteid = 'TEST6')`,
+		`DELETE FROM fits.site WHERE siteid = 'TEST6'`,
+	} {
+		if _, err := dbW.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := []struct {
+		id, method, url, body string
+		status                int
+	}{
+		{id: "create", method: "POST", url: "/site", status: http.StatusOK,
+			body: `{"networkID":"TN1","siteID":"TEST6","name":"Test site 6","longitude":176.0,"latitude":-38.0,"height":100.0,"groundRelationship":0.0}`},
+		{id: "observation before move", method: "POST", url: "/observation", status: http.StatusOK,
+			body: `[{"networkID":"TN1","siteID":"TEST6","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2007-01-30T12:00:00Z","value":1.1,"error":0.1}]`},
+		{id: "move", method: "PUT", url: "/site", status: http.StatusOK,
+			body: `{"networkID":"TN1","siteID":"TEST6","name":"Test site 6","longitude":176.5,"latitude":-38.0,"height":100.0,"groundRelationship":0.0,"validFrom":"2007-02-01T00:00:00Z"}`},
+		{id: "move before current version", method: "PUT", url: "/site", status: http.StatusBadRequest,
+			body: `{"networkID":"TN1","siteID":"TEST6","name":"Test site 6","longitude":176.7,"latitude":-38.0,"height":100.0,"groundRelationship":0.0,"validFrom":"2007-01-01T00:00:00Z"}`},
+		{id: "observation after move", method: "POST", url: "/observation", status: http.StatusOK,
+			body: `[{"networkID":"TN1","siteID":"TEST6","typeID":"t2","methodID":"m1","sampleID":"none","systemID":"none","time":"2007-02-02T12:00:00Z","value":1.2,"error":0.1}]`},
+	}
+
+	for _, v := range in {
+		req, err := http.NewRequest(v.method, testServer.URL+v.url, strings.NewReader(v.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", v1JSON)
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != v.status {
+			t.Fatalf("%s: expected status %d got %d", v.id, v.status, res.StatusCode)
+		}
+	}
+
+	var versions []siteVersion
+
+	getJSON(t, "/site/history?networkID=TN1&siteID=TEST6", &versions)
+
+	moved := time.Date(2007, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions got %d", len(versions))
+	}
+
+	if versions[0].Longitude != 176.0 || versions[0].ValidFrom != nil || versions[0].ValidTo == nil || !versions[0].ValidTo.Equal(moved) {
+		t.Errorf("unexpected first version %+v", versions[0])
+	}
+
+	if versions[1].Longitude != 176.5 || versions[1].ValidFrom == nil || !versions[1].ValidFrom.Equal(moved) || versions[1].ValidTo != nil {
+		t.Errorf("unexpected current version %+v", versions[1])
+	}
+
+	// each observation should have the location that was valid when it was made.
+	res, err := http.Get(testServer.URL + "/observation?typeID=t2&start=2007-01-29T00:00:00Z&days=7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200 got %d", res.StatusCode)
+	}
+
+	var longitudes []float64
+
+	s := bufio.NewScanner(res.Body)
+	for s.Scan() {
+		f := strings.Split(s.Text(), ",")
+		if len(f) < 3 || f[1] != "TEST6" {
+			continue
+		}
+
+		l, err := strconv.ParseFloat(f[2], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		longitudes = append(longitudes, l)
+	}
+	if err = s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(longitudes) != 2 || longitudes[0] != 176.0 || longitudes[1] != 176.5 {
+		t.Errorf("expected longitudes 176.0 and 176.5 got %v", longitudes)
+	}
+}
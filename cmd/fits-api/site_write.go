@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/GeoNet/weft"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// siteW is a site to be created or updated.
+type siteW struct {
+	NetworkID          string   `json:"networkID"`
+	SiteID             string   `json:"siteID"`
+	Name               string   `json:"name"`
+	Longitude          *float64 `json:"longitude"`
+	Latitude           *float64 `json:"latitude"`
+	Height             *float64 `json:"height"`
+	GroundRelationship *float64 `json:"groundRelationship"`
+	// ValidFrom is when changes in an update took effect e.g., when the site moved.  Defaults to now.
+	ValidFrom *time.Time `json:"validFrom"`
+}
+
+// siteCreate adds a new site.  It is an error if the site already exists.
+func siteCreate(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	return siteAdd(r, h, b, false)
+}
+
+// siteUpdate updates an existing site.  It is an error if the site doesn't exist.
+func siteUpdate(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	return siteAdd(r, h, b, true)
+}
+
+/*
+siteAdd reads a site as JSON from the request body and adds it to the DB with fits.add_site.
+exists is the expected state of the site before the request.  For an update the previous version of the
+site is kept in fits.site_history.  The GeoJSON for the site is written to b.
+*/
+func siteAdd(r *http.Request, h http.Header, b *bytes.Buffer, exists bool) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var s siteW
+
+	if res := decodeJSON(r, &s); !res.Ok {
+		return res
+	}
+
+	if res := s.valid(); !res.Ok {
+		return res
+	}
+
+	if res := validNetwork(s.NetworkID); !res.Ok {
+		if res.Code == http.StatusNotFound {
+			return weft.BadRequest("unknown networkID " + s.NetworkID)
+		}
+		return res
+	}
+
+	tx, err := dbW.Begin()
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+	defer tx.Rollback()
+
+	var found bool
+	var validFrom *time.Time
+
+	// lock the site so the current version can't change before it is updated.
+	err = tx.QueryRow(`SELECT valid_from FROM fits.site join fits.network using (networkpk) WHERE siteid = $2 AND networkid = $1 FOR UPDATE`,
+		s.NetworkID, s.SiteID).Scan(&validFrom)
+	switch err {
+	case nil:
+		found = true
+	case sql.ErrNoRows:
+	default:
+		return weft.ServiceUnavailableError(err)
+	}
+
+	switch {
+	case exists && !found:
+		return &weft.NotFound
+	case !exists && found:
+		return &weft.Result{Ok: false, Code: http.StatusConflict, Msg: "site " + s.NetworkID + "." + s.SiteID + " already exists"}
+	case !exists && s.ValidFrom != nil:
+		return weft.BadRequest("validFrom can only be used to update a site")
+	}
+
+	t := time.Now().UTC()
+
+	if s.ValidFrom != nil {
+		t = *s.ValidFrom
+
+		switch {
+		case t.After(time.Now().UTC()):
+			return weft.BadRequest("validFrom can not be in the future")
+		case validFrom != nil && !t.After(*validFrom):
+			return weft.BadRequest("validFrom must be after the current version of the site is valid from " + validFrom.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if _, err = tx.Exec(`SELECT fits.add_site($1, $2, $3, $4, $5, $6, $7, $8)`,
+		s.NetworkID, s.SiteID, s.Name, *s.Longitude, *s.Latitude, *s.Height, *s.GroundRelationship, t); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return writeSite(s.NetworkID, s.SiteID, h, b)
+}
+
+/*
+siteDecommission sets the time a site stopped being used.  The optional time
+query parameter (RFC3339) defaults to now.
+*/
+func siteDecommission(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID"}, []string{"time"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	t := time.Now().UTC()
+
+	if v.Get("time") != "" {
+		var err error
+		if t, err = time.Parse(time.RFC3339, v.Get("time")); err != nil {
+			return weft.BadRequest("Invalid time query param.")
+		}
+	}
+
+	var found bool
+
+	if err := dbW.QueryRow(`SELECT fits.decommission_site($1, $2, $3)`, v.Get("networkID"), v.Get("siteID"), t).Scan(&found); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if !found {
+		return &weft.NotFound
+	}
+
+	return writeSite(v.Get("networkID"), v.Get("siteID"), h, b)
+}
+
+// writeSite writes the GeoJSON for the site to b.
+func writeSite(networkID, siteID string, h http.Header, b *bytes.Buffer) *weft.Result {
+	by, err := geoJSONSite(networkID, siteID)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	h.Set("Content-Type", v1GeoJSON)
+	b.Write(by)
+
+	return &weft.StatusOK
+}
+
+// valid checks all fields are set and that the location, height, and ground relationship are sensible.
+func (s siteW) valid() *weft.Result {
+	switch {
+	case s.NetworkID == "":
+		return weft.BadRequest("missing networkID")
+	case s.SiteID == "":
+		return weft.BadRequest("missing siteID")
+	case s.Name == "":
+		return weft.BadRequest("missing name")
+	case s.Longitude == nil:
+		return weft.BadRequest("missing longitude")
+	case s.Latitude == nil:
+		return weft.BadRequest("missing latitude")
+	case s.Height == nil:
+		return weft.BadRequest("missing height")
+	case s.GroundRelationship == nil:
+		return weft.BadRequest("missing groundRelationship")
+	case !finite(*s.Longitude) || *s.Longitude < -180.0 || *s.Longitude > 180.0:
+		return weft.BadRequest("invalid longitude")
+	case !finite(*s.Latitude) || *s.Latitude < -90.0 || *s.Latitude > 90.0:
+		return weft.BadRequest("invalid latitude")
+	// from the deepest ocean trench to the highest mountain.
+	case !finite(*s.Height) || *s.Height < -11000.0 || *s.Height > 9000.0:
+		return weft.BadRequest("invalid height")
+	case !finite(*s.GroundRelationship) || math.Abs(*s.GroundRelationship) > 12000.0:
+		return weft.BadRequest("invalid groundRelationship")
+	}
+
+	return &weft.StatusOK
+}
+
+// validNetwork checks that the networkID exists in the DB.
+func validNetwork(networkID string) *weft.Result {
+	var d string
+
+	if err := db.QueryRow("select networkID FROM fits.network where networkID = $1", networkID).Scan(&d); err != nil {
+		if err == sql.ErrNoRows {
+			return &weft.NotFound
+		}
+		return weft.ServiceUnavailableError(err)
+	}
+
+	return &weft.StatusOK
+}
+
+// decodeJSON decodes the JSON request body into v.  The request must have
+// Content-Type application/json;version=1
+func decodeJSON(r *http.Request, v interface{}) *weft.Result {
+	if r.Header.Get("Content-Type") != v1JSON {
+		return weft.BadRequest("Content-Type must be " + v1JSON)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if err == io.EOF {
+			return weft.BadRequest("empty request body")
+		}
+		return weft.BadRequest("invalid JSON: " + err.Error())
+	}
+
+	return &weft.StatusOK
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
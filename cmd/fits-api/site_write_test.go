@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSiteWrite(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// remove the site from any earlier test run.
+	for _, q := range []string{
+		`DELETE FROM fits.site_history WHERE sitepk IN (SELECT sitepk FROM fits.site WHERE siteid = 'TEST4')`,
+		`DELETE FROM fits.site WHERE siteid = 'TEST4'`,
+	} {
+		if _, err := dbW.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := []struct {
+		id, method, url, body string
+		status                int
+	}{
+		{id: "create", method: "POST", url: "/site", status: http.StatusOK,
+			body: `{"networkID":"TN1","siteID":"TEST4","name":"Test site 4","longitude":176.2,"latitude":-38.1,"height":100.0,"groundRelationship":0.0}`},
+		{id: "create exists", method: "POST", url: "/site", status: http.StatusConflict,
+			body: `{"networkID":"TN1","siteID":"TEST4","name":"Test site 4","longitude":176.2,"latitude":-38.1,"height":100.0,"groundRelationship":0.0}`},
+		{id: "update", method: "PUT", url: "/site", status: http.StatusOK,
+			body: `{"networkID":"TN1","siteID":"TEST4","name":"Test site 4","longitude":176.2,"latitude":-38.1,"height":110.0,"groundRelationship":0.0}`},
+		{id: "update not found", method: "PUT", url: "/site", status: http.StatusNotFound,
+			body: `{"networkID":"TN1","siteID":"TEST5","name":"Test site 5","longitude":176.2,"latitude":-38.1,"height":110.0,"groundRelationship":0.0}`},
+		{id: "unknown network", method: "POST", url: "/site", status: http.StatusBadRequest,
+			body: `{"networkID":"TN9","siteID":"TEST5","name":"Test site 5","longitude":176.2,"latitude":-38.1,"height":110.0,"groundRelationship":0.0}`},
+		{id: "invalid latitude", method: "POST", url: "/site", status: http.StatusBadRequest,
+			body: `{"networkID":"TN1","siteID":"TEST5","name":"Test site 5","longitude":176.2,"latitude":-98.1,"height":110.0,"groundRelationship":0.0}`},
+		{id: "missing height", method: "POST", url: "/site", status: http.StatusBadRequest,
+			body: `{"networkID":"TN1","siteID":"TEST5","name":"Test site 5","longitude":176.2,"latitude":-38.1,"groundRelationship":0.0}`},
+		{id: "decommission", method: "DELETE", url: "/site?networkID=TN1&siteID=TEST4&time=2016-01-01T00:00:00Z", status: http.StatusOK},
+	}
+
+	for _, v := range in {
+		req, err := http.NewRequest(v.method, testServer.URL+v.url, strings.NewReader(v.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.body != "" {
+			req.Header.Set("Content-Type", v1JSON)
+		}
+		req.SetBasicAuth("test", "test")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != v.status {
+			t.Errorf("%s: expected status %d got %d", v.id, v.status, res.StatusCode)
+		}
+
+		if v.status == http.StatusOK && res.Header.Get("Content-Type") != v1GeoJSON {
+			t.Errorf("%s: expected Content-Type %s got %s", v.id, v1GeoJSON, res.Header.Get("Content-Type"))
+		}
+	}
+}
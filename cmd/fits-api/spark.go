@@ -9,7 +9,7 @@ import (
 )
 
 func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"siteID", "typeID", "networkID"}, []string{"days", "yrange", "type", "stddev", "label"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"siteID", "typeID", "networkID"}, []string{"days", "start", "end", "yrange", "type", "stddev", "label", "qc", "unit", "format", "width", "height", "yscale"}); !res.Ok {
 		return res
 	}
 
@@ -20,16 +20,29 @@ func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	var plotType string
 	var s siteQ
 	var t typeQ
-	var days int
+	var start, end time.Time
 	var ymin, ymax float64
+	var logY bool
 	var stddev string
 	var label string
+	var qc []string
+	var u unitQ
+	var format string
+	var l ts.Layout
 	var res *weft.Result
 
 	if plotType, res = getPlotType(v); !res.Ok {
 		return res
 	}
 
+	if format, res = getFormat(v); !res.Ok {
+		return res
+	}
+
+	if l, res = getLayout(v); !res.Ok {
+		return res
+	}
+
 	if stddev, res = getStddev(v); !res.Ok {
 		return res
 	}
@@ -38,7 +51,7 @@ func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return res
 	}
 
-	if days, res = getDays(v); !res.Ok {
+	if start, end, res = getTimeRange(v); !res.Ok {
 		return res
 	}
 
@@ -46,6 +59,14 @@ func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return res
 	}
 
+	if logY, res = getYScale(v, ymin, ymax); !res.Ok {
+		return res
+	}
+
+	if qc, res = getQC(v); !res.Ok {
+		return res
+	}
+
 	if t, res = getType(v); !res.Ok {
 		return res
 	}
@@ -54,14 +75,17 @@ func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return res
 	}
 
+	if u, res = getUnit(v, t.typeID, t.unit); !res.Ok {
+		return res
+	}
+
 	var p plt
-	var tmin time.Time
 
-	if days > 0 {
-		n := time.Now().UTC()
-		tmin = n.Add(time.Duration(days*-1) * time.Hour * 24)
-		p.SetXAxis(tmin, n)
-		days = 0 // add all data > than tmin
+	switch {
+	case !start.IsZero() && !end.IsZero():
+		p.SetXAxis(start, end)
+	case !start.IsZero():
+		p.SetXAxis(start, time.Now().UTC())
 	}
 
 	switch {
@@ -72,45 +96,56 @@ func spark(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		p.SetYAxis(ymin, ymax)
 	}
 
-	p.SetUnit(t.unit)
+	if logY {
+		p.SetYLog()
+	}
+
+	p.SetUnit(u.symbol)
 
 	var err error
 
+	q := obsQ{
+		typeID: t.typeID,
+		start:  start,
+		end:    end,
+		qc:     qc,
+		unit:   u,
+	}
+
 	if stddev == `pop` {
-		err = p.setStddevPop(s, t, tmin, days)
+		err = p.setStddevPop(s, q)
 	}
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
-	err = p.addSeries(t, tmin, days, s)
+	err = p.addSeries(q, aggQ{}, outlierQ{}, s)
 	if err != nil {
 		return weft.ServiceUnavailableError(err)
 	}
 
+	var d plotter
+
 	switch plotType {
 	case ``, `line`:
 		switch label {
 		case ``, `all`:
-			err = ts.SparkLineAll.Draw(p.Plot, b)
+			d = &ts.SparkLineAll
 		case `latest`:
-			err = ts.SparkLineLatest.Draw(p.Plot, b)
+			d = &ts.SparkLineLatest
 		case `none`:
-			err = ts.SparkLineNone.Draw(p.Plot, b)
+			d = &ts.SparkLineNone
 		}
 	case `scatter`:
 		switch label {
 		case ``, `all`:
-			err = ts.SparkScatterAll.Draw(p.Plot, b)
+			d = &ts.SparkScatterAll
 		case `latest`:
-			err = ts.SparkScatterLatest.Draw(p.Plot, b)
+			d = &ts.SparkScatterLatest
 		case `none`:
-			err = ts.SparkScatterNone.Draw(p.Plot, b)
+			d = &ts.SparkScatterNone
 		}
 	}
-	if err != nil {
-		return weft.ServiceUnavailableError(err)
-	}
 
-	return &weft.StatusOK
+	return writePlot(d, p.Plot, l, format, h, b)
 }
@@ -4,17 +4,48 @@ import (
 	"bytes"
 	"database/sql"
 	"github.com/GeoNet/weft"
+	"github.com/lib/pq"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	spatialObsGeoJSON = `SELECT row_to_json(fc)
+		FROM ( SELECT 'FeatureCollection' as type, COALESCE(array_to_json(array_agg(f)), '[]') as features
+		FROM (SELECT 'Feature' as type,
+		ST_AsGeoJSON(ST_Transform(location::geometry, $4))::json as geometry,
+		row_to_json((SELECT l FROM
+			(
+				SELECT
+				networkid AS "networkID",
+				siteid AS "siteID",
+				height,
+				ground_relationship AS "groundRelationship",
+				to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') AS time,
+				value,
+				error
+			) as l
+		)) as properties FROM `
+	spatialObsGeoJSONEnd = ` order by siteid asc, time asc) As f )  as fc`
+
+	// spatialObsJoin is the join from the observations to the site versions and networks.
+	spatialObsJoin = ` join ` + siteVersions + ` using (sitepk) join fits.network using (networkpk) `
+)
+
+/*
+spatialObs returns observations for all sites with typeID over a time range.
+Returns CSV by default or a GeoJSON FeatureCollection, with one Feature per observation,
+if the Accept header is application/vnd.geo+json;version=1.  Coordinates are in srsName.
+The site location, height, and ground relationship are those that were valid at the time of each observation.
+Values and errors are converted to the unit query parameter if it is set.
+*/
 func spatialObs(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
-	if res := weft.CheckQuery(r, []string{"typeID", "days", "start"}, []string{"srsName", "within", "methodID"}); !res.Ok {
+	if res := weft.CheckQuery(r, []string{"typeID", "days", "start"}, []string{"srsName", "within", "methodID", "unit"}); !res.Ok {
 		return res
 	}
-	h.Set("Content-Type", "text/csv;version=1")
+	h.Set("Content-Type", v1CSV)
 
 	v := r.URL.Query()
 
@@ -83,52 +114,54 @@ func spatialObs(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		return weft.ServiceUnavailableError(err)
 	}
 
+	var u unitQ
+
+	if u, res = getUnit(v, typeID, unit); !res.Ok {
+		return res
+	}
+
+	unit = u.symbol
+	table := obsQ{unit: u}.table()
+
+	args := []interface{}{typeID, start, end, srid}
+	where := `WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1)
+		AND time >= $2 and time < $3
+		AND (site.valid_from IS NULL OR time >= site.valid_from) AND (site.valid_to IS NULL OR time < site.valid_to)`
+
+	if within != "" {
+		args = append(args, within)
+		where += ` AND ST_Within(location::geometry, ST_GeomFromText($` + strconv.Itoa(len(args)) + `, 4326))`
+	}
+
+	if methodID != "" {
+		args = append(args, methodID)
+		where += ` AND methodpk = (SELECT methodpk FROM fits.method WHERE methodid = $` + strconv.Itoa(len(args)) + `)`
+	}
+
+	if r.Header.Get("Accept") == v1GeoJSON {
+		h.Set("Content-Type", v1GeoJSON)
+
+		var d string
+
+		if err = db.QueryRow(spatialObsGeoJSON+table+spatialObsJoin+where+spatialObsGeoJSONEnd, args...).Scan(&d); err != nil {
+			return transformError(err)
+		}
+
+		b.WriteString(d)
+
+		return &weft.StatusOK
+	}
+
 	var d string
-	var rows *sql.Rows
 
-	switch {
-	case within == "" && methodID == "":
-		rows, err = db.Query(
-			`SELECT format('%s,%s,%s,%s,%s,%s,%s,%s,%s', networkid, siteid,  
-		ST_X(ST_Transform(location::geometry, $4)), ST_Y(ST_Transform(location::geometry, $4)),
-		height,ground_relationship, to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) 
-		as csv FROM fits.observation join fits.site using (sitepk) join fits.network using (networkpk)
-		WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1) AND 
-		time >= $2 and time < $3 order by siteid asc`, typeID, start, end, srid)
-	case within != "" && methodID == "":
-		rows, err = db.Query(
-			`SELECT format('%s,%s,%s,%s,%s,%s,%s,%s,%s', networkid, siteid,  
-		ST_X(ST_Transform(location::geometry, $4)), ST_Y(ST_Transform(location::geometry, $4)),
-		height,ground_relationship, to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) 
-		as csv FROM fits.observation join fits.site using (sitepk) join fits.network using (networkpk)
-		WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1) 
-		AND  ST_Within(location::geometry, ST_GeomFromText($5, 4326))
-		AND time >= $2 and time < $3 order by siteid asc`, typeID, start, end, srid, within)
-	case within == "" && methodID != "":
-		rows, err = db.Query(
-			`SELECT format('%s,%s,%s,%s,%s,%s,%s,%s,%s', networkid, siteid,  
-		ST_X(ST_Transform(location::geometry, $4)), ST_Y(ST_Transform(location::geometry, $4)),
-		height,ground_relationship, to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) 
-		as csv FROM fits.observation join fits.site using (sitepk) join fits.network using (networkpk)
-		WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1) 
-		AND methodpk = (SELECT methodpk FROM fits.method WHERE methodid = $5)
-		AND time >= $2 and time < $3 order by siteid asc`, typeID, start, end, srid, methodID)
-	case within != "" && methodID != "":
-		rows, err = db.Query(
-			`SELECT format('%s,%s,%s,%s,%s,%s,%s,%s,%s', networkid, siteid,  
+	rows, err := db.Query(
+		`SELECT format('%s,%s,%s,%s,%s,%s,%s,%s,%s', networkid, siteid,
 		ST_X(ST_Transform(location::geometry, $4)), ST_Y(ST_Transform(location::geometry, $4)),
-		height,ground_relationship, to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error) 
-		as csv FROM fits.observation join fits.site using (sitepk) join fits.network using (networkpk)
-		WHERE typepk = (SELECT typepk FROM fits.type WHERE typeid = $1) 
-		AND methodpk = (SELECT methodpk FROM fits.method WHERE methodid = $6)
-		AND  ST_Within(location::geometry, ST_GeomFromText($5, 4326))
-		AND time >= $2 and time < $3 order by siteid asc`, typeID, start, end, srid, within, methodID)
-	}
+		height,ground_relationship, to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'), value, error)
+		as csv FROM `+table+spatialObsJoin+`
+		`+where+` order by siteid asc, time asc`, args...)
 	if err != nil {
-		// not sure what a transformation error would look like.
-		// Return any errors as a 404.  Could improve this by inspecting
-		// the error type to check for net dial errors that should 503.
-		return &weft.NotFound
+		return transformError(err)
 	}
 	defer rows.Close()
 
@@ -142,6 +175,9 @@ func spatialObs(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 		b.Write([]byte(d))
 		b.Write(eol)
 	}
+	if err = rows.Err(); err != nil {
+		return transformError(err)
+	}
 	rows.Close()
 
 	if methodID != "" {
@@ -153,6 +189,19 @@ func spatialObs(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
 	return &weft.StatusOK
 }
 
+/*
+transformError returns not found for PostGIS errors transforming site locations to
+the requested srs e.g., for locations outside the area of the projection.  Other
+errors are service unavailable.
+*/
+func transformError(err error) *weft.Result {
+	if e, ok := err.(*pq.Error); ok && strings.Contains(e.Message, "transform") {
+		return &weft.NotFound
+	}
+
+	return weft.ServiceUnavailableError(err)
+}
+
 // validSrs checks that the srs represented by auth and srid exists in the DB.
 func validSrs(auth string, srid int) *weft.Result {
 	var d string
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/GeoNet/weft"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultPercentiles are used for obstats if the percentiles query parameter is not set.
+var defaultPercentiles = []float64{5, 25, 75, 95}
+
+/*
+getPercentiles returns the percentiles from the comma separated percentiles query parameter.
+Returns defaultPercentiles if the parameter is not set.
+*/
+func getPercentiles(v url.Values) ([]float64, *weft.Result) {
+	if v.Get("percentiles") == "" {
+		return defaultPercentiles, &weft.StatusOK
+	}
+
+	s := strings.Split(v.Get("percentiles"), ",")
+
+	if len(s) > 100 {
+		return nil, weft.BadRequest("too many percentiles.")
+	}
+
+	var pc []float64
+
+	for i := range s {
+		p, err := strconv.ParseFloat(s[i], 64)
+		if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+			return nil, weft.BadRequest("invalid percentiles query param.")
+		}
+		pc = append(pc, p)
+	}
+
+	return pc, &weft.StatusOK
+}
+
+/*
+summarise sets the statistics for values that don't come from the DB.
+values must be in time order.  pc are the percentiles to find.
+*/
+func (s *obstats) summarise(values []value, pc []float64) {
+	s.Count = len(values)
+
+	if s.Count == 0 {
+		return
+	}
+
+	s.First = &values[0]
+	s.Last = &values[len(values)-1]
+
+	iMin, iMax, _ := extremes(values)
+	s.Minimum = &values[iMin]
+	s.Maximum = &values[iMax]
+
+	sorted := make([]float64, len(values))
+	for i := range values {
+		sorted[i] = values[i].V
+	}
+	sort.Float64s(sorted)
+
+	s.Median = quantile(sorted, 0.5)
+
+	for _, p := range pc {
+		s.Percentiles = append(s.Percentiles, percentile{Percentile: p, Value: quantile(sorted, p/100.0)})
+	}
+
+	dev := make([]float64, len(sorted))
+	for i := range sorted {
+		dev[i] = math.Abs(sorted[i] - s.Median)
+	}
+	sort.Float64s(dev)
+
+	s.MAD = quantile(dev, 0.5)
+
+	if s.Count > 1 {
+		var mean, ss float64
+		for i := range sorted {
+			mean += sorted[i]
+		}
+		mean /= float64(s.Count)
+
+		for i := range sorted {
+			ss += (sorted[i] - mean) * (sorted[i] - mean)
+		}
+
+		s.StddevSample = math.Sqrt(ss / float64(s.Count-1))
+	}
+
+	s.SpanSeconds = s.Last.T.Sub(s.First.T).Seconds()
+
+	if s.Count < 2 {
+		return
+	}
+
+	intervals := make([]float64, len(values)-1)
+
+	for i := 1; i < len(values); i++ {
+		intervals[i-1] = values[i].T.Sub(values[i-1].T).Seconds()
+
+		if s.LargestGap == nil || intervals[i-1] > s.LargestGap.Seconds {
+			s.LargestGap = &gap{Start: values[i-1].T, End: values[i].T, Seconds: intervals[i-1]}
+		}
+	}
+
+	sort.Float64s(intervals)
+
+	s.MedianIntervalSeconds = quantile(intervals, 0.5)
+}
+
+/*
+quantile returns the q quantile (0 <= q <= 1) of sorted using linear interpolation
+between closest ranks.  sorted must not be empty.
+*/
+func quantile(sorted []float64, q float64) float64 {
+	r := q * float64(len(sorted)-1)
+	i := int(math.Floor(r))
+
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+
+	return sorted[i] + (r-float64(i))*(sorted[i+1]-sorted[i])
+}
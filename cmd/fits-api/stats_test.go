@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummarise(t *testing.T) {
+	t0 := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	values := []value{
+		{T: t0, V: 3.0},
+		{T: t0.Add(time.Hour), V: 1.0},
+		{T: t0.Add(2 * time.Hour), V: 4.0},
+		{T: t0.Add(5 * time.Hour), V: 1.0},
+		{T: t0.Add(6 * time.Hour), V: 5.0},
+	}
+
+	var s obstats
+	s.summarise(values, []float64{0, 25, 100})
+
+	for _, c := range []struct {
+		id          string
+		expected, v float64
+	}{
+		{"count", 5, float64(s.Count)},
+		{"median", 3.0, s.Median},
+		{"p0", 1.0, s.Percentiles[0].Value},
+		{"p25", 1.0, s.Percentiles[1].Value},
+		{"p100", 5.0, s.Percentiles[2].Value},
+		{"mad", 2.0, s.MAD},
+		{"stddev sample", math.Sqrt(3.2), s.StddevSample},
+		{"span", 6 * 3600, s.SpanSeconds},
+		{"median interval", 3600, s.MedianIntervalSeconds},
+		{"largest gap", 3 * 3600, s.LargestGap.Seconds},
+		{"min", 1.0, s.Minimum.V},
+		{"max", 5.0, s.Maximum.V},
+		{"last", 5.0, s.Last.V},
+	} {
+		if math.Abs(c.expected-c.v) > 1e-9 {
+			t.Errorf("%s: expected %f got %f", c.id, c.expected, c.v)
+		}
+	}
+
+	if !s.LargestGap.Start.Equal(t0.Add(2 * time.Hour)) {
+		t.Errorf("expected largest gap to start at %s got %s", t0.Add(2*time.Hour), s.LargestGap.Start)
+	}
+
+	var e obstats
+	e.summarise([]value{}, defaultPercentiles)
+
+	if e.Count != 0 || e.First != nil || e.LargestGap != nil {
+		t.Errorf("expected empty stats got %+v", e)
+	}
+}
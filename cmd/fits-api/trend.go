@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/GeoNet/weft"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// year is the length of a year for rates.
+const year = 365.25 * 24 * time.Hour
+
+// maxBreaks limits the number of break epochs for a trend.
+const maxBreaks = 20
+
+type trend struct {
+	Epoch     time.Time // the reference epoch for Intercept.
+	Rate      float64   // per year.
+	RateError float64
+	Intercept float64 // value at Epoch.
+	RMS       float64 // residual RMS.
+	Count     int
+	Offsets   []offset // the offset at each break epoch.
+	Unit      string
+}
+
+type offset struct {
+	DateTime time.Time
+	Offset   float64
+	Error    float64
+}
+
+/*
+observationTrend fits a weighted least squares line to observations.  Optional break epochs
+add a step offset to the model at each break.
+*/
+func observationTrend(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"siteID", "networkID", "typeID"}, []string{"days", "start", "end", "methodID", "breaks"}); !res.Ok {
+		return res
+	}
+
+	h.Set("Content-Type", "application/json;version=1")
+
+	v := r.URL.Query()
+
+	typeID := v.Get("typeID")
+	var res *weft.Result
+
+	if res = validType(typeID); !res.Ok {
+		return res
+	}
+
+	var start, end time.Time
+
+	if start, end, res = getTimeRange(v); !res.Ok {
+		return res
+	}
+
+	var breaks []time.Time
+
+	if breaks, res = getBreaks(v); !res.Ok {
+		return res
+	}
+
+	var methodID string
+
+	if v.Get("methodID") != "" {
+		methodID = v.Get("methodID")
+		if res = validTypeMethod(typeID, methodID); !res.Ok {
+			return res
+		}
+	}
+
+	var unit string
+	if err := db.QueryRow("select symbol FROM fits.type join fits.unit using (unitPK) where typeID = $1",
+		typeID).Scan(&unit); err != nil {
+		if err == sql.ErrNoRows {
+			return &weft.NotFound
+		}
+		return weft.ServiceUnavailableError(err)
+	}
+
+	values, err := loadObs(obsQ{
+		networkID: v.Get("networkID"),
+		siteID:    v.Get("siteID"),
+		typeID:    typeID,
+		methodID:  methodID,
+		start:     start,
+		end:       end,
+	})
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	if len(values) == 0 {
+		return &weft.NotFound
+	}
+
+	t, err := fitTrend(values, breaks)
+	if err != nil {
+		return weft.BadRequest(err.Error())
+	}
+
+	t.Unit = unit
+
+	by, err := json.Marshal(t)
+	if err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.Write(by)
+
+	return &weft.StatusOK
+}
+
+// getBreaks returns the sorted break epochs from the comma separated RFC3339 breaks query parameter.
+func getBreaks(v url.Values) ([]time.Time, *weft.Result) {
+	if v.Get("breaks") == "" {
+		return nil, &weft.StatusOK
+	}
+
+	s := strings.Split(v.Get("breaks"), ",")
+
+	if len(s) > maxBreaks {
+		return nil, weft.BadRequest("too many breaks.")
+	}
+
+	var breaks []time.Time
+
+	for i := range s {
+		t, err := time.Parse(time.RFC3339, s[i])
+		if err != nil {
+			return nil, weft.BadRequest("invalid breaks query param.")
+		}
+		breaks = append(breaks, t)
+	}
+
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].Before(breaks[j]) })
+
+	return breaks, &weft.StatusOK
+}
+
+/*
+fitTrend fits the model
+
+	value = Intercept + Rate * (t - Epoch) + sum(Offset_k * H(t - break_k))
+
+to values by weighted least squares where H is the step function.  The weight for each
+value is 1/error^2.  If any error is zero all values are weighted equally.  Epoch is the time of
+the first value.  Parameter uncertainties are scaled by the a posteriori variance factor.
+
+values must be in time order.  There must be observations either side of each break and more
+observations than model parameters.
+*/
+func fitTrend(values []value, breaks []time.Time) (trend, error) {
+	var t trend
+
+	n := len(values)
+	p := 2 + len(breaks)
+
+	if n <= p {
+		return t, errors.New("not enough observations to fit the trend")
+	}
+
+	t.Epoch = values[0].T
+	t.Count = n
+
+	for i := range breaks {
+		if !breaks[i].After(values[0].T) || !breaks[i].Before(values[n-1].T) ||
+			(i > 0 && !breaks[i].After(breaks[i-1])) {
+			return t, errors.New("breaks must be between the first and last observation")
+		}
+	}
+
+	weighted := true
+	for _, v := range values {
+		if v.E <= 0 {
+			weighted = false
+			break
+		}
+	}
+
+	// normal equations N x = u
+	nm := make([][]float64, p)
+	for i := range nm {
+		nm[i] = make([]float64, p)
+	}
+	u := make([]float64, p)
+	a := make([]float64, p)
+
+	row := func(v value) {
+		a[0] = 1.0
+		a[1] = float64(v.T.Sub(t.Epoch)) / float64(year)
+		for k := range breaks {
+			a[2+k] = 0.0
+			if !v.T.Before(breaks[k]) {
+				a[2+k] = 1.0
+			}
+		}
+	}
+
+	weight := func(v value) float64 {
+		if weighted {
+			return 1.0 / (v.E * v.E)
+		}
+		return 1.0
+	}
+
+	for _, v := range values {
+		row(v)
+		w := weight(v)
+
+		for i := 0; i < p; i++ {
+			u[i] += w * a[i] * v.V
+			for j := 0; j < p; j++ {
+				nm[i][j] += w * a[i] * a[j]
+			}
+		}
+	}
+
+	q, err := invert(nm)
+	if err != nil {
+		return t, errors.New("can't fit the trend, there must be observations between each break")
+	}
+
+	x := make([]float64, p)
+	for i := 0; i < p; i++ {
+		for j := 0; j < p; j++ {
+			x[i] += q[i][j] * u[j]
+		}
+	}
+
+	var sumR2, sumWR2 float64
+
+	for _, v := range values {
+		row(v)
+
+		var m float64
+		for i := 0; i < p; i++ {
+			m += a[i] * x[i]
+		}
+
+		r := v.V - m
+		sumR2 += r * r
+		sumWR2 += weight(v) * r * r
+	}
+
+	f := sumWR2 / float64(n-p)
+
+	t.Intercept = x[0]
+	t.Rate = x[1]
+	t.RateError = math.Sqrt(q[1][1] * f)
+	t.RMS = math.Sqrt(sumR2 / float64(n))
+
+	for k := range breaks {
+		t.Offsets = append(t.Offsets, offset{
+			DateTime: breaks[k],
+			Offset:   x[2+k],
+			Error:    math.Sqrt(q[2+k][2+k] * f),
+		})
+	}
+
+	return t, nil
+}
+
+// invert returns the inverse of the square matrix m using Gauss-Jordan elimination
+// with partial pivoting.  m is not modified.
+func invert(m [][]float64) ([][]float64, error) {
+	n := len(m)
+
+	var tol float64
+	for i := range m {
+		tol = math.Max(tol, math.Abs(m[i][i]))
+	}
+	tol *= 1e-12
+
+	// augmented matrix [m | I]
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, 2*n)
+		copy(a[i], m[i])
+		a[i][n+i] = 1.0
+	}
+
+	for c := 0; c < n; c++ {
+		piv := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(a[r][c]) > math.Abs(a[piv][c]) {
+				piv = r
+			}
+		}
+
+		if math.Abs(a[piv][c]) <= tol {
+			return nil, errors.New("singular matrix")
+		}
+
+		a[c], a[piv] = a[piv], a[c]
+
+		d := a[c][c]
+		for j := range a[c] {
+			a[c][j] /= d
+		}
+
+		for r := 0; r < n; r++ {
+			if r == c || a[r][c] == 0 {
+				continue
+			}
+			f := a[r][c]
+			for j := range a[r] {
+				a[r][j] -= f * a[c][j]
+			}
+		}
+	}
+
+	inv := make([][]float64, n)
+	for i := range inv {
+		inv[i] = a[i][n:]
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitTrend(t *testing.T) {
+	t0 := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	brk := t0.Add(2 * year)
+
+	// 4 years of weekly values with a rate of 12.5 /yr, intercept 3.0, and a 7.0 step at brk.
+	var values []value
+	for d := time.Duration(0); d < 4*year; d += 7 * 24 * time.Hour {
+		v := value{T: t0.Add(d), V: 3.0 + 12.5*float64(d)/float64(year), E: 0.5}
+		if !v.T.Before(brk) {
+			v.V += 7.0
+		}
+		values = append(values, v)
+	}
+
+	tr, err := fitTrend(values, []time.Time{brk})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		id          string
+		expected, v float64
+	}{
+		{"rate", 12.5, tr.Rate},
+		{"intercept", 3.0, tr.Intercept},
+		{"offset", 7.0, tr.Offsets[0].Offset},
+		{"rms", 0.0, tr.RMS},
+		{"rate error", 0.0, tr.RateError},
+	} {
+		if math.Abs(c.expected-c.v) > 1e-6 {
+			t.Errorf("%s: expected %f got %f", c.id, c.expected, c.v)
+		}
+	}
+
+	if tr.Count != len(values) {
+		t.Errorf("expected count %d got %d", len(values), tr.Count)
+	}
+
+	// no break gives a different rate and a non zero residual.
+	tr, err = fitTrend(values, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(tr.Rate-12.5) < 1.0 || tr.RMS == 0.0 || tr.RateError == 0.0 {
+		t.Errorf("unexpected fit with no break: %+v", tr)
+	}
+
+	if _, err = fitTrend(values[:3], []time.Time{brk}); err == nil {
+		t.Error("expected error for too few values")
+	}
+
+	if _, err = fitTrend(values, []time.Time{t0.Add(-time.Hour)}); err == nil {
+		t.Error("expected error for break before the first value")
+	}
+}
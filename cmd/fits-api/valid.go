@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"github.com/GeoNet/fits/internal/ts"
 	"github.com/GeoNet/weft"
 	"net/url"
 	"strconv"
@@ -45,9 +46,76 @@ func getPlotType(v url.Values) (string, *weft.Result) {
 	}
 }
 
+func getFormat(v url.Values) (string, *weft.Result) {
+	switch v.Get("format") {
+	case ``, `svg`, ts.PNG, ts.PDF:
+		return v.Get("format"), &weft.StatusOK
+	default:
+		return ``, weft.BadRequest("invalid format")
+	}
+}
+
+// maxWidth and maxHeight limit the size of plot images.
+const (
+	maxWidth  = 2000
+	maxHeight = 1000
+)
+
+/*
+getLayout returns the layout for the width and height query params, in px.
+Zero values are left for the default size for the plot.
+*/
+func getLayout(v url.Values) (ts.Layout, *weft.Result) {
+	var l ts.Layout
+	var err error
+
+	if v.Get("width") != "" {
+		l.Width, err = strconv.Atoi(v.Get("width"))
+		if err != nil || l.Width <= 0 || l.Width > maxWidth {
+			return l, weft.BadRequest("invalid width query param.")
+		}
+	}
+
+	if v.Get("height") != "" {
+		l.Height, err = strconv.Atoi(v.Get("height"))
+		if err != nil || l.Height <= 0 || l.Height > maxHeight {
+			return l, weft.BadRequest("invalid height query param.")
+		}
+	}
+
+	return l, &weft.StatusOK
+}
+
 func getType(v url.Values) (typeQ, *weft.Result) {
+	return lookupType(v.Get("typeID"))
+}
+
+/*
+getTypes returns the two types for a comma separated typeID query param e.g., for
+a plot with two y axes.
+*/
+func getTypes(v url.Values) ([]typeQ, *weft.Result) {
+	ids := strings.Split(v.Get("typeID"), ",")
+	if len(ids) != 2 || ids[0] == "" || ids[1] == "" || ids[0] == ids[1] {
+		return nil, weft.BadRequest("typeID must be one type or two different types.")
+	}
+
+	var t []typeQ
+
+	for _, id := range ids {
+		q, res := lookupType(id)
+		if !res.Ok {
+			return nil, res
+		}
+		t = append(t, q)
+	}
+
+	return t, &weft.StatusOK
+}
+
+func lookupType(typeID string) (typeQ, *weft.Result) {
 	t := typeQ{
-		typeID: v.Get("typeID"),
+		typeID: typeID,
 	}
 
 	err := db.QueryRow("select type.name, type.description, unit.symbol FROM fits.type join fits.unit using (unitpk) where typeID = $1",
@@ -124,6 +192,63 @@ func getStart(v url.Values) (time.Time, *weft.Result) {
 	return t, &weft.StatusOK
 }
 
+/*
+getTimeRange returns the start and end of a time range from the start, end, and days query parameters.
+start and end are RFC3339.  Either of start or end can be used with days:
+
+	days - the last days up to now.
+	start, days - days after start.
+	end, days - days before end.
+	start, end - between start and end.
+
+A zero start or end does not restrict the range.
+*/
+func getTimeRange(v url.Values) (start, end time.Time, res *weft.Result) {
+	var days int
+
+	if start, res = getStart(v); !res.Ok {
+		return
+	}
+
+	if v.Get("end") != "" {
+		var err error
+		end, err = time.Parse(time.RFC3339, v.Get("end"))
+		if err != nil {
+			res = weft.BadRequest("Invalid end query param.")
+			return
+		}
+	}
+
+	if days, res = getDays(v); !res.Ok {
+		return
+	}
+
+	if days < 0 {
+		res = weft.BadRequest("Invalid days query param.")
+		return
+	}
+
+	d := time.Duration(days) * time.Hour * 24
+
+	switch {
+	case days != 0 && !start.IsZero() && !end.IsZero():
+		res = weft.BadRequest("Only two of start, end, and days can be used.")
+		return
+	case days != 0 && !start.IsZero():
+		end = start.Add(d)
+	case days != 0 && !end.IsZero():
+		start = end.Add(-d)
+	case days != 0:
+		start = time.Now().UTC().Add(-d)
+	}
+
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		res = weft.BadRequest("end must be after start.")
+	}
+
+	return
+}
+
 /*
 Returns 0 if days not set
 */
@@ -152,6 +277,103 @@ func getShowMethod(v url.Values) (bool, *weft.Result) {
 	}
 }
 
+func getShowSample(v url.Values) (bool, *weft.Result) {
+	switch v.Get("showSample") {
+	case "", "false":
+		return false, &weft.StatusOK
+	case "true":
+		return true, &weft.StatusOK
+	default:
+		return false, weft.BadRequest("invalid showSample")
+	}
+}
+
+// getShowQC returns true if the qc flag should be included in CSV observations.
+func getShowQC(v url.Values) (bool, *weft.Result) {
+	switch v.Get("showQC") {
+	case "", "false":
+		return false, &weft.StatusOK
+	case "true":
+		return true, &weft.StatusOK
+	default:
+		return false, weft.BadRequest("invalid showQC")
+	}
+}
+
+func getCorrected(v url.Values) (bool, *weft.Result) {
+	switch v.Get("corrected") {
+	case "", "false":
+		return false, &weft.StatusOK
+	case "true":
+		return true, &weft.StatusOK
+	default:
+		return false, weft.BadRequest("invalid corrected")
+	}
+}
+
+func getShowVisual(v url.Values) (bool, *weft.Result) {
+	switch v.Get("showVisual") {
+	case "", "false":
+		return false, &weft.StatusOK
+	case "true":
+		return true, &weft.StatusOK
+	default:
+		return false, weft.BadRequest("invalid showVisual")
+	}
+}
+
+/*
+getSample returns the systemID and sampleID query parameters.  Both are
+empty if not set.  sampleID can only be used with systemID.
+*/
+func getSample(v url.Values) (string, string, *weft.Result) {
+	systemID := v.Get("systemID")
+	sampleID := v.Get("sampleID")
+
+	switch {
+	case systemID == "" && sampleID == "":
+		return "", "", &weft.StatusOK
+	case systemID == "":
+		return "", "", weft.BadRequest("systemID must be specified when sampleID is specified.")
+	case sampleID == "":
+		return systemID, "", validSystem(systemID)
+	default:
+		return systemID, sampleID, validSample(systemID, sampleID)
+	}
+}
+
+// qcFlags are the valid quality control flags for observations.
+var qcFlags = []string{"good", "suspect", "bad", "unverified"}
+
+func validQC(f string) bool {
+	for _, q := range qcFlags {
+		if f == q {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+getQC returns the qc query parameter.  A comma separated list of qc flags.
+Returns nil if not set.
+*/
+func getQC(v url.Values) ([]string, *weft.Result) {
+	if v.Get("qc") == "" {
+		return nil, &weft.StatusOK
+	}
+
+	qc := strings.Split(v.Get("qc"), ",")
+
+	for _, f := range qc {
+		if !validQC(f) {
+			return nil, weft.BadRequest("invalid qc query param.")
+		}
+	}
+
+	return qc, &weft.StatusOK
+}
+
 /*
 ymin, ymax = 0 - not set
 ymin = ymin and != 0 - single range value
@@ -187,3 +409,27 @@ func getYRange(v url.Values) (float64, float64, *weft.Result) {
 	}
 	return ymin, ymax, &weft.StatusOK
 }
+
+/*
+getYScale returns true for a log y axis from the yscale query param.  A log y axis can only be
+used with a yrange that is a pair of increasing values > 0.  ymin and ymax are from getYRange.
+*/
+func getYScale(v url.Values, ymin, ymax float64) (bool, *weft.Result) {
+	switch v.Get("yscale") {
+	case ``, `linear`:
+		return false, &weft.StatusOK
+	case `log`:
+	default:
+		return false, weft.BadRequest("invalid yscale query param.")
+	}
+
+	switch {
+	case ymin == 0 && ymax == 0:
+	case ymin == ymax:
+		return false, weft.BadRequest("yrange must be a pair of values for yscale=log.")
+	case ymin <= 0 || ymax <= ymin:
+		return false, weft.BadRequest("yrange must be increasing values > 0 for yscale=log.")
+	}
+
+	return true, &weft.StatusOK
+}
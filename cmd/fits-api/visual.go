@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/GeoNet/fits/internal/ts"
+	"github.com/GeoNet/weft"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	visualJSON = `SELECT row_to_json(fc)
+		FROM (SELECT COALESCE(array_to_json(array_agg(v)), '[]') as "visualObservation"
+		FROM (SELECT networkid AS "networkID",
+			siteid AS "siteID",
+			to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') AS time,
+			image_url AS "imageURL",
+			notes
+		FROM fits.visual_observation join fits.site using (sitepk) join fits.network using (networkpk) `
+	visualJSONEnd = ` ORDER BY time ASC) as v) as fc`
+
+	visualGeoJSON = `SELECT row_to_json(fc)
+		FROM ( SELECT 'FeatureCollection' as type, COALESCE(array_to_json(array_agg(f)), '[]') as features
+		FROM (SELECT 'Feature' as type,
+		ST_AsGeoJSON(location)::json as geometry,
+		row_to_json((SELECT l FROM
+			(
+				SELECT
+				networkid AS "networkID",
+				siteid AS "siteID",
+				to_char(time, 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"') AS time,
+				image_url AS "imageURL",
+				notes
+			) as l
+		)) as properties FROM fits.visual_observation join fits.site using (sitepk) join fits.network using (networkpk) `
+	visualGeoJSONEnd = ` ORDER BY time ASC) As f )  as fc`
+)
+
+// visualW is a visual observation to be added.
+type visualW struct {
+	NetworkID string `json:"networkID"`
+	SiteID    string `json:"siteID"`
+	Time      string `json:"time"`
+	ImageURL  string `json:"imageURL"`
+	Notes     string `json:"notes"`
+}
+
+/*
+visualObservation returns visual observations for a site as JSON or GeoJSON depending on the Accept header.
+*/
+func visualObservation(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{"networkID", "siteID"}, []string{"start", "days"}); !res.Ok {
+		return res
+	}
+
+	v := r.URL.Query()
+
+	var start time.Time
+	var days int
+	var res *weft.Result
+
+	if start, res = getStart(v); !res.Ok {
+		return res
+	}
+
+	if days, res = getDays(v); !res.Ok {
+		return res
+	}
+
+	if res = validSite(v.Get("networkID"), v.Get("siteID")); !res.Ok {
+		return res
+	}
+
+	var q, end string
+
+	switch r.Header.Get("Accept") {
+	case v1GeoJSON:
+		h.Set("Content-Type", v1GeoJSON)
+		q, end = visualGeoJSON, visualGeoJSONEnd
+	default:
+		h.Set("Content-Type", v1JSON)
+		q, end = visualJSON, visualJSONEnd
+	}
+
+	where, args := visualWhere(v.Get("networkID"), v.Get("siteID"), start, days)
+
+	var d string
+
+	if err := db.QueryRow(q+where+end, args...).Scan(&d); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	b.WriteString(d)
+
+	return &weft.StatusOK
+}
+
+/*
+visualWhere returns the WHERE clause and arguments for visual observations at a site.
+to select all visual observations leave start and days 0
+to select visual observations in the last n days set start == 0 and days != 0
+to select visual observations after start set start != 0 and days == 0
+to select n days of visual observations after start set start != 0 and days != 0
+*/
+func visualWhere(networkID, siteID string, start time.Time, days int) (string, []interface{}) {
+	args := []interface{}{networkID, siteID}
+	w := `WHERE networkid = $1 AND siteid = $2`
+
+	if start.IsZero() && days > 0 {
+		start = time.Now().UTC().Add(time.Duration(days*-1) * time.Hour * 24)
+		days = 0
+	}
+
+	if !start.IsZero() {
+		args = append(args, start)
+		w += ` AND time > $3`
+	}
+
+	if !start.IsZero() && days > 0 {
+		args = append(args, start.Add(time.Duration(days)*time.Hour*24))
+		w += ` AND time < $4`
+	}
+
+	return w, args
+}
+
+// visualAdd adds or updates a visual observation.
+func visualAdd(r *http.Request, h http.Header, b *bytes.Buffer) *weft.Result {
+	if res := weft.CheckQuery(r, []string{}, []string{}); !res.Ok {
+		return res
+	}
+
+	var o visualW
+
+	if res := decodeJSON(r, &o); !res.Ok {
+		return res
+	}
+
+	switch {
+	case o.NetworkID == "":
+		return weft.BadRequest("missing networkID")
+	case o.SiteID == "":
+		return weft.BadRequest("missing siteID")
+	case o.ImageURL == "":
+		return weft.BadRequest("missing imageURL")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, o.Time)
+	if err != nil {
+		return weft.BadRequest("invalid time: " + o.Time)
+	}
+
+	if u, err := url.Parse(o.ImageURL); err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return weft.BadRequest("invalid imageURL: " + o.ImageURL)
+	}
+
+	if res := validSite(o.NetworkID, o.SiteID); !res.Ok {
+		if res.Code == http.StatusNotFound {
+			return weft.BadRequest(fmt.Sprintf("unknown site %s.%s", o.NetworkID, o.SiteID))
+		}
+		return res
+	}
+
+	if _, err = dbW.Exec(`SELECT fits.add_visual_observation($1, $2, $3, $4, $5)`,
+		o.NetworkID, o.SiteID, t, o.ImageURL, o.Notes); err != nil {
+		return weft.ServiceUnavailableError(err)
+	}
+
+	o.Time = t.UTC().Format(time.RFC3339Nano)
+
+	return writeJSON(o, h, b)
+}
+
+/*
+addVisualEvents adds visual observations for the site as events on the plot.
+start and days are as for visualWhere.
+*/
+func (plt *plt) addVisualEvents(s siteQ, start time.Time, days int) error {
+	where, args := visualWhere(s.networkID, s.siteID, start, days)
+
+	rows, err := db.Query(`SELECT time, notes FROM fits.visual_observation join fits.site using (sitepk) join fits.network using (networkpk) `+
+		where+` ORDER BY time ASC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e ts.Event
+		var notes string
+
+		if err = rows.Scan(&e.DateTime, &notes); err != nil {
+			return err
+		}
+
+		e.Label = e.DateTime.UTC().Format(time.RFC3339) + " " + notes
+		plt.AddEvent(e)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// columns are the columns that must be in the CSV header.
+var columns = []string{"networkID", "siteID", "typeID", "methodID", "sampleID", "systemID", "time", "value", "error"}
+
+// maxReport is the maximum number of unresolved rows to log.
+const maxReport = 10
+
+type loadStats struct {
+	read, skipped, merged int64
+}
+
+func loadFile(name, by string, skip bool) (loadStats, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return loadStats{}, err
+	}
+	defer f.Close()
+
+	return load(f, by, skip)
+}
+
+/*
+load reads CSV observations from r, copies them into a temporary staging table,
+resolves the site, type, method, and sample PKs, and then merges the observations into
+fits.observation in a single transaction.  by is recorded as who made the changes.
+*/
+func load(r io.Reader, by string, skip bool) (s loadStats, err error) {
+	c := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	c.TrimLeadingSpace = true
+
+	header, err := c.Read()
+	if err != nil {
+		return s, fmt.Errorf("reading CSV header: %s", err)
+	}
+
+	col := make(map[string]int)
+	for i, h := range header {
+		col[strings.TrimSpace(h)] = i
+	}
+
+	for _, k := range columns {
+		if _, ok := col[k]; !ok {
+			return s, fmt.Errorf("CSV header missing column: %s", k)
+		}
+	}
+
+	c.FieldsPerRecord = len(header)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return s, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`CREATE TEMP TABLE obs_load (
+		line BIGINT NOT NULL,
+		networkID TEXT NOT NULL,
+		siteID TEXT NOT NULL,
+		typeID TEXT NOT NULL,
+		methodID TEXT NOT NULL,
+		sampleID TEXT NOT NULL,
+		systemID TEXT NOT NULL,
+		time TIMESTAMP(6) WITH TIME ZONE NOT NULL,
+		value NUMERIC NOT NULL,
+		error NUMERIC NOT NULL
+		) ON COMMIT DROP`); err != nil {
+		return s, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("obs_load", "line", "networkid", "siteid", "typeid", "methodid", "sampleid", "systemid", "time", "value", "error"))
+	if err != nil {
+		return s, err
+	}
+
+	var line int64 = 1
+
+	for {
+		rec, err := c.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok && skip {
+				log.Printf("skipping line %d: %s", line, err)
+				s.skipped++
+				continue
+			}
+			stmt.Close()
+			return s, err
+		}
+
+		s.read++
+
+		t, v, e, err := parseRow(rec, col)
+		if err != nil {
+			if skip {
+				log.Printf("skipping line %d: %s", line, err)
+				s.skipped++
+				continue
+			}
+			stmt.Close()
+			return s, fmt.Errorf("line %d: %s", line, err)
+		}
+
+		if _, err = stmt.Exec(line, rec[col["networkID"]], rec[col["siteID"]], rec[col["typeID"]], rec[col["methodID"]],
+			rec[col["sampleID"]], rec[col["systemID"]], t, v, e); err != nil {
+			stmt.Close()
+			return s, err
+		}
+	}
+
+	// flush the COPY buffer.
+	if _, err = stmt.Exec(); err != nil {
+		stmt.Close()
+		return s, err
+	}
+
+	if err = stmt.Close(); err != nil {
+		return s, err
+	}
+
+	if _, err = tx.Exec(`ANALYZE obs_load`); err != nil {
+		return s, err
+	}
+
+	// Resolve the PKs for all rows in one query.  Rows that can't be resolved
+	// will have a NULL for at least one PK.
+	if _, err = tx.Exec(`CREATE TEMP TABLE obs_resolved ON COMMIT DROP AS
+		SELECT l.line, sn.sitepk, t.typepk, tm.methodpk, ss.samplepk, l.time, l.value, l.error
+		FROM obs_load l
+		LEFT JOIN (fits.site JOIN fits.network USING (networkpk)) sn ON sn.siteid = l.siteid AND sn.networkid = l.networkid
+		LEFT JOIN fits.type t ON t.typeid = l.typeid
+		LEFT JOIN (fits.method JOIN fits.type_method USING (methodpk)) tm ON tm.methodid = l.methodid AND tm.typepk = t.typepk
+		LEFT JOIN (fits.sample JOIN fits.system USING (systempk)) ss ON ss.sampleid = l.sampleid AND ss.systemid = l.systemid`); err != nil {
+		return s, err
+	}
+
+	var unresolved int64
+	if unresolved, err = reportUnresolved(tx); err != nil {
+		return s, err
+	}
+
+	if unresolved > 0 {
+		if !skip {
+			return s, fmt.Errorf("%d rows with unknown site, type, method, or sample", unresolved)
+		}
+		s.skipped += unresolved
+	}
+
+	// DISTINCT ON keeps the last row in the file if the same observation appears more
+	// than once.  ON CONFLICT can't update the same row twice in one statement.
+	if _, err = tx.Exec(`CREATE TEMP TABLE obs_merge ON COMMIT DROP AS
+		SELECT DISTINCT ON (sitepk, typepk, methodpk, samplepk, time) sitepk, typepk, methodpk, samplepk, time, value, error
+		FROM obs_resolved
+		WHERE sitepk IS NOT NULL AND typepk IS NOT NULL AND methodpk IS NOT NULL AND samplepk IS NOT NULL
+		ORDER BY sitepk, typepk, methodpk, samplepk, time, line DESC`); err != nil {
+		return s, err
+	}
+
+	// keep the previous version of observations that will change, the same as fits.add_observation.
+	if _, err = tx.Exec(`INSERT INTO fits.observation_revision (sitepk, typepk, methodpk, samplepk, time, value, error, qc,
+		published, published_by, replaced, replaced_by)
+		SELECT o.sitepk, o.typepk, o.methodpk, o.samplepk, o.time, o.value, o.error, o.qc, o.updated, o.updated_by, now(), $1
+		FROM fits.observation o JOIN obs_merge m USING (sitepk, typepk, methodpk, samplepk, time)
+		WHERE (o.value, o.error) IS DISTINCT FROM (m.value, m.error)
+		FOR UPDATE OF o`, by); err != nil {
+		return s, err
+	}
+
+	// updated, txid, change, and updated_by are maintained the same way as fits.add_observation.
+	res, err := tx.Exec(`INSERT INTO fits.observation (sitepk, typepk, methodpk, samplepk, time, value, error, updated_by)
+		SELECT sitepk, typepk, methodpk, samplepk, time, value, error, $1
+		FROM obs_merge
+		ON CONFLICT (sitepk, typepk, methodpk, samplepk, time) DO UPDATE SET value = EXCLUDED.value, error = EXCLUDED.error,
+		updated = CASE WHEN (observation.value, observation.error) IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.error)
+			THEN now() ELSE observation.updated END,
+		txid = CASE WHEN (observation.value, observation.error) IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.error)
+			THEN txid_current() ELSE observation.txid END,
+		change = CASE WHEN (observation.value, observation.error) IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.error)
+			THEN nextval('fits.observation_change_seq') ELSE observation.change END,
+		updated_by = CASE WHEN (observation.value, observation.error) IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.error)
+			THEN EXCLUDED.updated_by ELSE observation.updated_by END`, by)
+	if err != nil {
+		return s, err
+	}
+
+	if s.merged, err = res.RowsAffected(); err != nil {
+		return s, err
+	}
+
+	err = tx.Commit()
+
+	return s, err
+}
+
+// parseRow parses the time, value, and error from rec.
+func parseRow(rec []string, col map[string]int) (t time.Time, v, e float64, err error) {
+	if t, err = time.Parse(time.RFC3339Nano, strings.TrimSpace(rec[col["time"]])); err != nil {
+		err = fmt.Errorf("invalid time: %s", rec[col["time"]])
+		return
+	}
+
+	if v, err = strconv.ParseFloat(strings.TrimSpace(rec[col["value"]]), 64); err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		err = fmt.Errorf("invalid value: %s", rec[col["value"]])
+		return
+	}
+
+	if e, err = strconv.ParseFloat(strings.TrimSpace(rec[col["error"]]), 64); err != nil || e < 0 || math.IsNaN(e) || math.IsInf(e, 0) {
+		err = fmt.Errorf("invalid error: %s", rec[col["error"]])
+		return
+	}
+
+	return
+}
+
+// reportUnresolved logs up to maxReport rows that could not be resolved
+// and returns the total number of unresolved rows.
+func reportUnresolved(tx *sql.Tx) (n int64, err error) {
+	if err = tx.QueryRow(`SELECT count(*) FROM obs_resolved
+		WHERE sitepk IS NULL OR typepk IS NULL OR methodpk IS NULL OR samplepk IS NULL`).Scan(&n); err != nil || n == 0 {
+		return
+	}
+
+	rows, err := tx.Query(`SELECT l.line, l.networkid, l.siteid, l.typeid, l.methodid, l.systemid, l.sampleid,
+		r.sitepk IS NULL, r.typepk IS NULL, r.methodpk IS NULL, r.samplepk IS NULL
+		FROM obs_resolved r JOIN obs_load l USING (line)
+		WHERE r.sitepk IS NULL OR r.typepk IS NULL OR r.methodpk IS NULL OR r.samplepk IS NULL
+		ORDER BY l.line LIMIT $1`, maxReport)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line int64
+		var networkID, siteID, typeID, methodID, systemID, sampleID string
+		var noSite, noType, noMethod, noSample bool
+
+		if err = rows.Scan(&line, &networkID, &siteID, &typeID, &methodID, &systemID, &sampleID,
+			&noSite, &noType, &noMethod, &noSample); err != nil {
+			return
+		}
+
+		var msg []string
+		if noSite {
+			msg = append(msg, fmt.Sprintf("unknown site %s.%s", networkID, siteID))
+		}
+		if noType {
+			msg = append(msg, "unknown typeID "+typeID)
+		}
+		if noMethod {
+			msg = append(msg, fmt.Sprintf("methodID %s is not valid for typeID %s", methodID, typeID))
+		}
+		if noSample {
+			msg = append(msg, fmt.Sprintf("unknown sample %s for system %s", sampleID, systemID))
+		}
+
+		log.Printf("line %d: %s", line, strings.Join(msg, ", "))
+	}
+
+	if n > maxReport {
+		log.Printf("... and %d more unresolved rows", n-maxReport)
+	}
+
+	err = rows.Err()
+
+	return
+}
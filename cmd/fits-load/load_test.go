@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"github.com/GeoNet/fits/internal/dbconn"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setup connects to the DB.  Defer teardown() after setup().
+func setup(t *testing.T) {
+	var err error
+	db, err = dbconn.Open(os.Getenv("DB_WRITE_USER"), os.Getenv("DB_WRITE_PASSWD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func teardown() {
+	db.Close()
+}
+
+const header = "networkID,siteID,typeID,methodID,sampleID,systemID,time,value,error\n"
+
+func TestParseRow(t *testing.T) {
+	col := map[string]int{"time": 0, "value": 1, "error": 2}
+
+	in := []struct {
+		id  string
+		rec []string
+		t   time.Time
+		v   float64
+		e   float64
+		err bool
+	}{
+		{id: "valid", rec: []string{"2008-01-01T00:00:00Z", "1.5", "0.1"}, t: time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC), v: 1.5, e: 0.1},
+		{id: "spaces", rec: []string{" 2008-01-01T00:00:00.5Z ", " -2 ", " 0 "}, t: time.Date(2008, 1, 1, 0, 0, 0, 500000000, time.UTC), v: -2},
+		{id: "time", rec: []string{"2008-01-01", "1.5", "0.1"}, err: true},
+		{id: "value", rec: []string{"2008-01-01T00:00:00Z", "x", "0.1"}, err: true},
+		{id: "value NaN", rec: []string{"2008-01-01T00:00:00Z", "NaN", "0.1"}, err: true},
+		{id: "value Inf", rec: []string{"2008-01-01T00:00:00Z", "+Inf", "0.1"}, err: true},
+		{id: "error", rec: []string{"2008-01-01T00:00:00Z", "1.5", ""}, err: true},
+		{id: "error negative", rec: []string{"2008-01-01T00:00:00Z", "1.5", "-0.1"}, err: true},
+	}
+
+	for _, v := range in {
+		tm, val, e, err := parseRow(v.rec, col)
+		if (err != nil) != v.err {
+			t.Errorf("%s: expected error %t got %v", v.id, v.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if !tm.Equal(v.t) || val != v.v || e != v.e {
+			t.Errorf("%s: expected %s %g %g got %s %g %g", v.id, v.t, v.v, v.e, tm, val, e)
+		}
+	}
+}
+
+// TestLoadHeader checks the header before connecting to the DB.
+func TestLoadHeader(t *testing.T) {
+	for _, h := range []string{"", "networkID,siteID,typeID,methodID,sampleID,systemID,time,value\n"} {
+		if _, err := load(strings.NewReader(h), "test", false); err == nil {
+			t.Errorf("expected error for header %q", h)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setup(t)
+	defer teardown()
+
+	// remove data from any earlier test run.
+	for _, q := range []string{
+		`DELETE FROM fits.observation WHERE time >= '2008-01-01T00:00:00Z' AND time < '2009-01-01T00:00:00Z'`,
+		`DELETE FROM fits.observation_revision WHERE time >= '2008-01-01T00:00:00Z' AND time < '2009-01-01T00:00:00Z'`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var l bytes.Buffer
+	log.SetOutput(&l)
+	defer log.SetOutput(os.Stderr)
+
+	in := []struct {
+		id, csv string
+		skip    bool
+		err     bool
+		s       loadStats
+		logged  string // expected in the log.
+		values  map[string]float64
+	}{
+		{
+			id: "unresolved",
+			csv: header + "TN1,TEST1,t1,m1,none,none,2008-01-01T00:00:00Z,1.0,0.1\n" +
+				"TN1,NOSITE,t1,m1,none,none,2008-01-02T00:00:00Z,1.0,0.1\n",
+			err:    true,
+			logged: "line 3: unknown site TN1.NOSITE",
+		},
+		{
+			id: "unparsable",
+			csv: header + "TN1,TEST1,t1,m1,none,none,2008-01-01T00:00:00Z,1.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-01-02T00:00:00Z,x,0.1\n",
+			err: true,
+		},
+		{
+			id:     "method not valid for type",
+			csv:    header + "TN1,TEST1,t2,m2,none,none,2008-01-01T00:00:00Z,1.0,0.1\n",
+			err:    true,
+			logged: "line 2: methodID m2 is not valid for typeID t2",
+		},
+		{
+			id: "skip",
+			csv: header + "TN1,TEST1,t1,m1,none,none,2008-01-01T00:00:00Z,1.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-01-02T00:00:00Z,x,0.1\n" +
+				"TN1,NOSITE,t1,m1,none,none,2008-01-03T00:00:00Z,1.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-01-04T00:00:00Z,4.0,0.1\n",
+			skip:   true,
+			s:      loadStats{read: 4, skipped: 2, merged: 2},
+			values: map[string]float64{"2008-01-01T00:00:00Z": 1.0, "2008-01-04T00:00:00Z": 4.0},
+		},
+		{
+			id: "duplicates last line wins",
+			csv: header + "TN1,TEST1,t1,m1,none,none,2008-02-01T00:00:00Z,1.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-02-01T00:00:00Z,2.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-02-01T00:00:00Z,3.0,0.1\n",
+			s:      loadStats{read: 3, merged: 1},
+			values: map[string]float64{"2008-02-01T00:00:00Z": 3.0},
+		},
+		{
+			id: "update",
+			csv: header + "TN1,TEST1,t1,m1,none,none,2008-02-01T00:00:00Z,5.0,0.1\n" +
+				"TN1,TEST1,t1,m1,none,none,2008-01-01T00:00:00Z,1.0,0.1\n",
+			s:      loadStats{read: 2, merged: 2},
+			values: map[string]float64{"2008-02-01T00:00:00Z": 5.0, "2008-01-01T00:00:00Z": 1.0},
+		},
+	}
+
+	for _, v := range in {
+		l.Reset()
+
+		s, err := load(strings.NewReader(v.csv), "test", v.skip)
+		if (err != nil) != v.err {
+			t.Errorf("%s: expected error %t got %v", v.id, v.err, err)
+			continue
+		}
+
+		if s != v.s && !v.err {
+			t.Errorf("%s: expected %+v got %+v", v.id, v.s, s)
+		}
+
+		if !strings.Contains(l.String(), v.logged) {
+			t.Errorf("%s: expected log to contain %q got %q", v.id, v.logged, l.String())
+		}
+
+		for k, e := range v.values {
+			var val float64
+			if err := db.QueryRow(`SELECT value FROM fits.observation JOIN fits.site USING (sitepk)
+				WHERE siteid = 'TEST1' AND time = $1`, k).Scan(&val); err != nil {
+				t.Errorf("%s: %s: %s", v.id, k, err)
+				continue
+			}
+			if val != e {
+				t.Errorf("%s: %s: expected value %g got %g", v.id, k, e, val)
+			}
+		}
+	}
+
+	// the failed loads add nothing.
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM fits.observation
+		WHERE time >= '2008-01-01T00:00:00Z' AND time < '2009-01-01T00:00:00Z'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 observations in 2008 got %d", n)
+	}
+
+	// only the changed observation keeps its previous version.
+	if err := db.QueryRow(`SELECT count(*) FROM fits.observation_revision
+		WHERE time >= '2008-01-01T00:00:00Z' AND time < '2009-01-01T00:00:00Z' AND value = 3.0`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 revision got %d", n)
+	}
+
+	if err := db.QueryRow(`SELECT count(*) FROM fits.observation_revision
+		WHERE time >= '2008-01-01T00:00:00Z' AND time < '2009-01-01T00:00:00Z'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 revision in 2008 got %d", n)
+	}
+}
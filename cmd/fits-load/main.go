@@ -0,0 +1,89 @@
+/*
+fits-load bulk loads observations from CSV files into the FITS DB.
+
+The CSV files must have a header line naming the columns
+
+	networkID,siteID,typeID,methodID,sampleID,systemID,time,value,error
+
+in any order.  Time must be RFC3339 e.g., 2010-11-24T00:00:00Z
+
+Rows are copied into a staging table with COPY, the site, type, method, and sample
+are resolved in the DB, and then the observations are merged into fits.observation.
+Existing observations are updated with the same semantics as fits.add_observation, including
+keeping the previous versions of changed observations.  -by sets who is recorded as making the changes.
+
+Each file is loaded in a single transaction.  If any row in a file can't be parsed or resolved
+then no rows from that file are added, unless -skip is set.
+
+DB connection parameters are read from the same env vars as fits-api e.g., DB_HOST.  The user
+is DB_WRITE_USER with DB_WRITE_PASSWD, which must have write permissions.
+
+usage: fits-load [-skip] [-by name] file.csv [file.csv ...]
+*/
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/GeoNet/fits/internal/dbconn"
+	"log"
+	"os"
+)
+
+var (
+	db     *sql.DB
+	Prefix string // prefix for logging
+)
+
+var (
+	skip = flag.Bool("skip", false, "skip rows that can't be parsed or resolved instead of failing the file.")
+	by   = flag.String("by", "fits-load", "who to record as making changes to observations.")
+)
+
+func init() {
+	if Prefix != "" {
+		log.SetPrefix(Prefix + " ")
+	}
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: fits-load [-skip] [-by name] file.csv [file.csv ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	db, err = dbconn.Open(os.Getenv("DB_WRITE_USER"), os.Getenv("DB_WRITE_PASSWD"))
+	if err != nil {
+		log.Fatalf("ERROR: problem with DB config: %s", err)
+	}
+	defer db.Close()
+
+	if err = db.Ping(); err != nil {
+		log.Fatalf("ERROR: problem pinging DB: %s", err)
+	}
+
+	var failed bool
+
+	for _, f := range flag.Args() {
+		s, err := loadFile(f, *by, *skip)
+		if err != nil {
+			log.Printf("ERROR: %s: %s", f, err)
+			failed = true
+			continue
+		}
+
+		log.Printf("%s: read %d rows, skipped %d, merged %d observations", f, s.read, s.skipped, s.merged)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
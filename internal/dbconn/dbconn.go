@@ -0,0 +1,26 @@
+/*
+Package dbconn opens connections to the FITS DB so that the commands read the same connection parameters.
+*/
+package dbconn
+
+import (
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	"os"
+)
+
+/*
+Open opens a connection pool to the DB for user.  The other connection
+parameters are read from the env vars DB_HOST, DB_CONN_TIMEOUT, DB_NAME, and DB_SSLMODE.
+*/
+func Open(user, password string) (*sql.DB, error) {
+	return sql.Open("postgres",
+		fmt.Sprintf("host=%s connect_timeout=%s user=%s password=%s dbname=%s sslmode=%s",
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_CONN_TIMEOUT"),
+			user,
+			password,
+			os.Getenv("DB_NAME"),
+			os.Getenv("DB_SSLMODE")))
+}
@@ -0,0 +1,130 @@
+package ts
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+// SVGAvailability draws the Spans for a Plot as a timeline bar.
+type SVGAvailability struct {
+	template *template.Template // the name for the template must be "plot"
+	layout   Layout             // the default layout.
+}
+
+/*
+Draw draws the Spans for p to b.  Zero values in l are set from the default layout.
+Returns ErrLayout if l leaves too little room to draw the timeline.
+*/
+func (s *SVGAvailability) Draw(p Plot, l Layout, b *bytes.Buffer) error {
+	var err error
+
+	if p.plt.Layout, err = l.with(s.layout); err != nil {
+		return err
+	}
+
+	p.plt.width = p.plt.Layout.DataWidth()
+	p.plt.height = p.plt.Layout.DataHeight()
+
+	p.scaleSpans()
+	p.setXAxis()
+
+	return s.template.ExecuteTemplate(b, "plot", p.plt)
+}
+
+// Availability has the timeline 600x20 px by default.
+var Availability = SVGAvailability{
+	template: template.Must(template.New("plot").Funcs(funcMap).Parse(availabilityTemplate)),
+	layout: Layout{
+		Width:        800,
+		Height:       110,
+		MarginTop:    40,
+		MarginRight:  130,
+		MarginBottom: 50,
+		MarginLeft:   70,
+		FontSize:     12,
+	},
+}
+
+/*
+scaleSpans sets the x axis range from the spans, if it wasn't set, and
+converts the spans to svg space.  Spans are at least 1px wide so short
+spans are visible.
+*/
+func (p *Plot) scaleSpans() {
+	if p.plt.XMin.IsZero() && p.plt.XMax.IsZero() {
+		for i, s := range p.plt.Spans {
+			if i == 0 || s.Start.Before(p.plt.XMin) {
+				p.plt.XMin = s.Start
+			}
+			if i == 0 || s.End.After(p.plt.XMax) {
+				p.plt.XMax = s.End
+			}
+		}
+	}
+
+	if !p.plt.XMax.After(p.plt.XMin) {
+		p.plt.XMax = p.plt.XMin.Add(time.Hour)
+	}
+
+	p.plt.dx = float64(p.plt.width) / p.plt.XMax.Sub(p.plt.XMin).Seconds()
+
+	p.plt.SpanPts = make([]spanPt, 0)
+
+	for _, s := range p.plt.Spans {
+		if s.End.Before(p.plt.XMin) || s.Start.After(p.plt.XMax) {
+			continue
+		}
+
+		start, end := s.Start, s.End
+		if start.Before(p.plt.XMin) {
+			start = p.plt.XMin
+		}
+		if end.After(p.plt.XMax) {
+			end = p.plt.XMax
+		}
+
+		x := int((start.Sub(p.plt.XMin).Seconds() * p.plt.dx) + 0.5)
+		w := int((end.Sub(p.plt.XMin).Seconds()*p.plt.dx)+0.5) - x
+		if w < 1 {
+			w = 1
+		}
+
+		p.plt.SpanPts = append(p.plt.SpanPts, spanPt{
+			X:   x,
+			W:   w,
+			Gap: s.Gap,
+			L:   s.Start.UTC().Format(time.RFC3339) + " - " + s.End.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+const availabilityTemplate = `<?xml version="1.0"?>
+{{$w := .Layout.DataWidth}}{{$h := .Layout.DataHeight}}{{$fs := .Layout.FontSize}}
+<svg width="{{.Layout.Width}}" height="{{.Layout.Height}}" xmlns="http://www.w3.org/2000/svg" font-family="Arial, sans-serif" font-size="{{$fs}}px" fill="darkslategrey">
+<rect x="0" y="0" width="{{.Layout.Width}}" height="{{.Layout.Height}}" fill="white"/>
+<g transform="translate({{.Layout.MarginLeft}},{{.Layout.MarginTop}})">
+<rect x="0" y="0" width="{{$w}}" height="{{$h}}" fill="whitesmoke"/>
+{{range .SpanPts}}
+<rect x="{{.X}}" y="0" width="{{.W}}" height="{{$h}}" fill="{{if .Gap}}orangered{{else}}forestgreen{{end}}"><title>{{if .Gap}}gap{{else}}data{{end}} {{.L}}</title></rect>
+{{end}}
+<polyline fill="none" stroke="black" stroke-width="1" points="0,{{$h}} {{$w}},{{$h}}"/>
+{{range .Axes.X}}
+{{if .L}}
+<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},{{sub $h 4}} {{.X}},{{add $h 4}}"/>
+<text x="{{.X}}" y="{{add $h $fs 6}}" text-anchor="middle">{{.L}}</text>
+{{else}}
+<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},{{sub $h 2}} {{.X}},{{add $h 2}}"/>
+{{end}}
+{{end}}
+<text x="{{half $w}}" y="-{{add $fs 3}}" text-anchor="middle"  font-size="{{add $fs 4}}px"  fill="black">{{.Axes.Title}}</text>
+</g>
+<g transform="translate({{add .Layout.MarginLeft $w 20}},{{add .Layout.MarginTop 5}})">
+<rect x="0" y="-5" width="10" height="10" fill="forestgreen"/>
+<text x="14" y="0" text-anchor="start" dominant-baseline="middle">data</text>
+<rect x="0" y="10" width="10" height="10" fill="orangered"/>
+<text x="14" y="15" text-anchor="start" dominant-baseline="middle">gap</text>
+</g>
+<text x="5" y="{{sub .Layout.Height 2}}" text-anchor="start">CC BY 3.0 NZ GNS Science</text>
+</svg>
+`
@@ -0,0 +1,182 @@
+package ts
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+// Formats for Image and RenderMap.
+const (
+	PNG = "png"
+	PDF = "pdf"
+)
+
+/*
+canvas is implemented by the image formats.  Coordinates are in px from the top left of the image
+and text is drawn with the Go fonts so all formats have the same glyphs.
+*/
+type canvas interface {
+	// path draws the line through pts, closing it if closed is true.
+	path(pts []point, closed bool, s paint)
+	circle(c point, r float64, s paint)
+	// text draws s with the start of the baseline at p.
+	text(p point, s string, f textStyle, c color.NRGBA)
+	textWidth(s string, f textStyle) float64
+	encode(b *bytes.Buffer) error
+}
+
+// newCanvas returns a w by h px canvas for format.
+func newCanvas(format string, w, h int) (canvas, error) {
+	switch format {
+	case PNG:
+		return newPNGCanvas(w, h), nil
+	case PDF:
+		return newPDFCanvas(w, h)
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+}
+
+type point struct {
+	x, y float64
+}
+
+// paint is the fill and stroke for a shape.  Colours with zero alpha are not drawn.
+type paint struct {
+	fill, stroke color.NRGBA
+	width        float64
+	dash         []float64 // lengths of dashes and gaps for the stroke.
+}
+
+// stroke returns a paint for lines in c.
+func stroke(c string, width float64) paint {
+	return paint{stroke: colour(c), width: width}
+}
+
+// anchors for text.
+const (
+	start = iota
+	middle
+	end
+)
+
+type textStyle struct {
+	size   float64
+	italic bool
+	anchor int
+	// centre is true if y is the middle of the text rather than the baseline.
+	centre bool
+	// rotate is true for text that reads down the image, as for rotate(90) in SVG.
+	rotate bool
+}
+
+// run is text drawn in one colour.
+type run struct {
+	s string
+	c color.NRGBA
+}
+
+/*
+colour returns the colour for an SVG colour name or #rrggbb value.  none and unknown
+colours are returned with zero alpha so they are not drawn.
+*/
+func colour(s string) color.NRGBA {
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil {
+			return color.NRGBA{}
+		}
+		return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+	}
+
+	c, ok := colornames.Map[strings.ToLower(s)]
+	if !ok {
+		return color.NRGBA{}
+	}
+
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+}
+
+// fade returns c with its alpha scaled by opacity.
+func fade(c color.NRGBA, opacity float64) color.NRGBA {
+	c.A = uint8(float64(c.A)*opacity + 0.5)
+	return c
+}
+
+// drawing draws on a canvas with the origin at x, y e.g., for the data area of a plot.
+type drawing struct {
+	c    canvas
+	x, y float64
+}
+
+// at returns a drawing with the origin moved by x, y.
+func (d drawing) at(x, y int) drawing {
+	return drawing{c: d.c, x: d.x + float64(x), y: d.y + float64(y)}
+}
+
+func (d drawing) pts(xy []int) []point {
+	p := make([]point, len(xy)/2)
+	for i := range p {
+		p[i] = point{x: d.x + float64(xy[2*i]), y: d.y + float64(xy[2*i+1])}
+	}
+	return p
+}
+
+// line draws a line through the x, y pairs in xy.
+func (d drawing) line(s paint, xy ...int) {
+	d.c.path(d.pts(xy), false, s)
+}
+
+// polygon draws the closed shape through the x, y pairs in xy.
+func (d drawing) polygon(s paint, xy ...int) {
+	d.c.path(d.pts(xy), true, s)
+}
+
+func (d drawing) rect(x, y, w, h int, fill color.NRGBA) {
+	d.polygon(paint{fill: fill}, x, y, x+w, y, x+w, y+h, x, y+h)
+}
+
+func (d drawing) dot(x, y int, r float64, s paint) {
+	d.c.circle(point{x: d.x + float64(x), y: d.y + float64(y)}, r, s)
+}
+
+// text draws the runs as one line at x, y.
+func (d drawing) text(x, y int, f textStyle, runs ...run) {
+	var w float64
+	for _, r := range runs {
+		w += d.c.textWidth(r.s, f)
+	}
+
+	var o float64
+	switch f.anchor {
+	case middle:
+		o = -w / 2
+	case end:
+		o = -w
+	}
+
+	// the middle of the text is about a third of the size above the baseline.
+	var c float64
+	if f.centre {
+		c = f.size * 0.35
+	}
+
+	p := point{x: d.x + float64(x) + o, y: d.y + float64(y) + c}
+	if f.rotate {
+		p = point{x: d.x + float64(x) - c, y: d.y + float64(y) + o}
+	}
+
+	for _, r := range runs {
+		d.c.text(p, r.s, f, r.c)
+		if f.rotate {
+			p.y += d.c.textWidth(r.s, f)
+		} else {
+			p.x += d.c.textWidth(r.s, f)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package ts
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+RenderMap draws a map from map180 to b as a PNG or PDF image.  map180 only draws maps as SVG
+so the elements it uses are drawn on the same canvas as plots; paths with straight lines,
+circles, rects, and text, in groups that are translated.  Elements that are hidden until there
+is interaction, or that use filters, are left out.  rects are drawn with square corners.
+*/
+func RenderMap(svg []byte, format string, b *bytes.Buffer) error {
+	if format != PNG && format != PDF {
+		return fmt.Errorf("unknown format %s", format)
+	}
+
+	m := svgMap{d: xml.NewDecoder(bytes.NewReader(svg)), format: format}
+
+	if err := m.draw(); err != nil {
+		return err
+	}
+
+	if m.c == nil {
+		return fmt.Errorf("no svg element found")
+	}
+
+	return m.c.encode(b)
+}
+
+type svgMap struct {
+	d      *xml.Decoder
+	format string
+	c      canvas
+	// the origin for each open element.
+	origin []drawing
+}
+
+func (m *svgMap) draw() error {
+	for {
+		tok, err := m.d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "svg" && m.c == nil:
+				var w, h int
+				if w, err = strconv.Atoi(attr(t, "width")); err != nil {
+					return err
+				}
+				if h, err = strconv.Atoi(attr(t, "height")); err != nil {
+					return err
+				}
+				if m.c, err = newCanvas(m.format, w, h); err != nil {
+					return err
+				}
+				m.origin = append(m.origin, drawing{c: m.c})
+				continue
+			case m.c == nil:
+				return fmt.Errorf("%s before svg element", t.Name.Local)
+			case t.Name.Local == "title" || t.Name.Local == "desc" || t.Name.Local == "defs" || t.Name.Local == "set",
+				attr(t, "visibility") == "hidden", attr(t, "filter") != "":
+				if err = m.d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			d := m.origin[len(m.origin)-1]
+
+			if err = m.drawElement(d, t); err != nil {
+				return err
+			}
+
+			// drawing text reads to the end of the element.
+			if t.Name.Local == "text" {
+				continue
+			}
+
+			if s := attr(t, "transform"); s != "" {
+				var x, y float64
+				if _, err = fmt.Sscanf(s, "translate(%g,%g)", &x, &y); err != nil {
+					return fmt.Errorf("unsupported transform %s", s)
+				}
+				d = drawing{c: d.c, x: d.x + x, y: d.y + y}
+			}
+
+			m.origin = append(m.origin, d)
+		case xml.EndElement:
+			if len(m.origin) > 0 {
+				m.origin = m.origin[:len(m.origin)-1]
+			}
+		}
+	}
+}
+
+func (m *svgMap) drawElement(d drawing, t xml.StartElement) error {
+	s, err := svgPaint(t)
+	if err != nil {
+		return err
+	}
+
+	switch t.Name.Local {
+	case "path":
+		p, err := pathData(attr(t, "d"))
+		if err != nil {
+			return err
+		}
+		for _, sub := range p {
+			for i := range sub.pts {
+				sub.pts[i].x += d.x
+				sub.pts[i].y += d.y
+			}
+			d.c.path(sub.pts, sub.closed, s)
+		}
+	case "circle":
+		v, err := numbers(t, "cx", "cy", "r")
+		if err != nil {
+			return err
+		}
+		d.c.circle(point{x: d.x + v[0], y: d.y + v[1]}, v[2], s)
+	case "rect":
+		v, err := numbers(t, "x", "y", "width", "height")
+		if err != nil {
+			return err
+		}
+		x, y := d.x+v[0], d.y+v[1]
+		d.c.path([]point{{x, y}, {x + v[2], y}, {x + v[2], y + v[3]}, {x, y + v[3]}}, true, s)
+	case "text":
+		v, err := numbers(t, "x", "y", "font-size")
+		if err != nil {
+			return err
+		}
+
+		f := textStyle{size: v[2], italic: attr(t, "font-style") == "italic"}
+		switch attr(t, "text-anchor") {
+		case "middle":
+			f.anchor = middle
+		case "end":
+			f.anchor = end
+		}
+
+		var l bytes.Buffer
+		if err = m.chars(&l); err != nil {
+			return err
+		}
+
+		if l.Len() > 0 {
+			d.x, d.y = d.x+v[0], d.y+v[1]
+			d.text(0, 0, f, run{s: strings.Join(strings.Fields(l.String()), " "), c: s.fill})
+		}
+	}
+
+	return nil
+}
+
+// chars writes the text up to the end of the current element to b, leaving out child elements.
+func (m *svgMap) chars(b *bytes.Buffer) error {
+	for {
+		tok, err := m.d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			if err = m.d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// svgPaint returns the paint for t.  The fill is black by default as for SVG.
+func svgPaint(t xml.StartElement) (paint, error) {
+	s := paint{fill: colour("black"), width: 1}
+
+	if v := attr(t, "fill"); v != "" {
+		s.fill = colour(v)
+	}
+
+	s.stroke = colour(attr(t, "stroke"))
+
+	if v := attr(t, "stroke-width"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return s, err
+		}
+		s.width = w
+	}
+
+	if v := attr(t, "opacity"); v != "" {
+		o, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return s, err
+		}
+		s.fill, s.stroke = fade(s.fill, o), fade(s.stroke, o)
+	}
+
+	return s, nil
+}
+
+func attr(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// numbers returns the values of the named attributes of t, which must all be numbers.
+func numbers(t xml.StartElement, names ...string) ([]float64, error) {
+	v := make([]float64, len(names))
+
+	for i, n := range names {
+		var err error
+		if v[i], err = strconv.ParseFloat(attr(t, n), 64); err != nil {
+			return nil, fmt.Errorf("invalid %s for %s: %s", n, t.Name.Local, err)
+		}
+	}
+
+	return v, nil
+}
+
+type subpath struct {
+	pts    []point
+	closed bool
+}
+
+/*
+pathData parses SVG path data with straight lines, as drawn by ST_AsSVG and map180 markers.
+Coordinates after the first for M or m are lines.
+*/
+func pathData(d string) ([]subpath, error) {
+	f := strings.Fields(strings.Replace(d, ",", " ", -1))
+
+	var p []subpath
+	var cmd byte
+	var cur point
+
+	for i := 0; i < len(f); {
+		if c := f[i][0]; strings.IndexByte("MmLlZz", c) >= 0 {
+			cmd = c
+			f[i] = f[i][1:]
+			if f[i] == "" {
+				i++
+			}
+			if cmd == 'Z' || cmd == 'z' {
+				if len(p) > 0 {
+					p[len(p)-1].closed = true
+				}
+				continue
+			}
+		}
+
+		if cmd == 0 || cmd == 'Z' || cmd == 'z' || i+1 >= len(f) {
+			return nil, fmt.Errorf("invalid path data %s", d)
+		}
+
+		x, err := strconv.ParseFloat(f[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(f[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		i += 2
+
+		if cmd == 'm' || cmd == 'l' {
+			x, y = cur.x+x, cur.y+y
+		}
+		cur = point{x: x, y: y}
+
+		switch cmd {
+		case 'M', 'm':
+			p = append(p, subpath{pts: []point{cur}})
+			cmd = cmd + 'L' - 'M'
+		default:
+			if len(p) == 0 {
+				return nil, fmt.Errorf("invalid path data %s", d)
+			}
+			p[len(p)-1].pts = append(p[len(p)-1].pts, cur)
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,114 @@
+package ts
+
+import (
+	"bytes"
+	"image/color"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+/*
+pdfCanvas draws a single page PDF with vector graphics.  The page is the size of the image with
+one px to one point.  The fonts are embedded with only the glyphs used.
+*/
+type pdfCanvas struct {
+	pdf *gofpdf.Fpdf
+}
+
+func newPDFCanvas(w, h int) (*pdfCanvas, error) {
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "pt",
+		Size:    gofpdf.SizeType{Wd: float64(w), Ht: float64(h)},
+	})
+
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "I", goitalic.TTF)
+	pdf.SetLineJoinStyle("round")
+	pdf.AddPage()
+
+	return &pdfCanvas{pdf: pdf}, pdf.Error()
+}
+
+func (c *pdfCanvas) path(pts []point, closed bool, s paint) {
+	if len(pts) == 0 {
+		return
+	}
+
+	c.paint(s, func(style string) {
+		c.pdf.MoveTo(pts[0].x, pts[0].y)
+		for _, p := range pts[1:] {
+			c.pdf.LineTo(p.x, p.y)
+		}
+		if closed {
+			c.pdf.ClosePath()
+		}
+		c.pdf.DrawPath(style)
+	})
+}
+
+func (c *pdfCanvas) circle(p point, r float64, s paint) {
+	c.paint(s, func(style string) {
+		c.pdf.Circle(p.x, p.y, r, style)
+	})
+}
+
+/*
+paint calls draw with the PDF style for filling and then stroking a shape.  They are drawn
+separately as the alpha is set for both.
+*/
+func (c *pdfCanvas) paint(s paint, draw func(style string)) {
+	if s.fill.A > 0 {
+		c.alpha(s.fill)
+		c.pdf.SetFillColor(int(s.fill.R), int(s.fill.G), int(s.fill.B))
+		draw("F")
+	}
+
+	if s.stroke.A > 0 && s.width > 0 {
+		c.alpha(s.stroke)
+		c.pdf.SetDrawColor(int(s.stroke.R), int(s.stroke.G), int(s.stroke.B))
+		c.pdf.SetLineWidth(s.width)
+		c.pdf.SetDashPattern(s.dash, 0)
+		draw("D")
+	}
+}
+
+func (c *pdfCanvas) alpha(col color.NRGBA) {
+	c.pdf.SetAlpha(float64(col.A)/255, "Normal")
+}
+
+func (c *pdfCanvas) font(f textStyle) {
+	var style string
+	if f.italic {
+		style = "I"
+	}
+
+	c.pdf.SetFont("go", style, f.size)
+}
+
+func (c *pdfCanvas) text(p point, s string, f textStyle, col color.NRGBA) {
+	c.font(f)
+	c.alpha(col)
+	c.pdf.SetTextColor(int(col.R), int(col.G), int(col.B))
+
+	if f.rotate {
+		c.pdf.TransformBegin()
+		c.pdf.TransformRotate(-90, p.x, p.y)
+		defer c.pdf.TransformEnd()
+	}
+
+	c.pdf.Text(p.x, p.y, s)
+}
+
+func (c *pdfCanvas) textWidth(s string, f textStyle) float64 {
+	c.font(f)
+
+	return c.pdf.GetStringWidth(s)
+}
+
+func (c *pdfCanvas) encode(b *bytes.Buffer) error {
+	return c.pdf.Output(b)
+}
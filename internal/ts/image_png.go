@@ -0,0 +1,123 @@
+package ts
+
+import (
+	"bytes"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// the Go fonts have glyphs for Latin, Greek, and Cyrillic text and the symbols used in units.
+var (
+	goRegular = mustParse(goregular.TTF)
+	goItalic  = mustParse(goitalic.TTF)
+)
+
+func mustParse(ttf []byte) *truetype.Font {
+	f, err := truetype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+type faceKey struct {
+	size   float64
+	italic bool
+}
+
+// pngCanvas draws an anti-aliased PNG image.
+type pngCanvas struct {
+	dc    *gg.Context
+	faces map[faceKey]font.Face
+}
+
+func newPNGCanvas(w, h int) *pngCanvas {
+	c := &pngCanvas{dc: gg.NewContext(w, h), faces: make(map[faceKey]font.Face)}
+	c.dc.SetLineJoinRound()
+
+	return c
+}
+
+func (c *pngCanvas) path(pts []point, closed bool, s paint) {
+	if len(pts) == 0 {
+		return
+	}
+
+	c.dc.MoveTo(pts[0].x, pts[0].y)
+	for _, p := range pts[1:] {
+		c.dc.LineTo(p.x, p.y)
+	}
+	if closed {
+		c.dc.ClosePath()
+	}
+
+	c.paint(s)
+}
+
+func (c *pngCanvas) circle(p point, r float64, s paint) {
+	c.dc.DrawCircle(p.x, p.y, r)
+	c.paint(s)
+}
+
+// paint fills and strokes the current path and then clears it.
+func (c *pngCanvas) paint(s paint) {
+	if s.fill.A > 0 {
+		c.dc.SetColor(s.fill)
+		c.dc.FillPreserve()
+	}
+
+	if s.stroke.A > 0 && s.width > 0 {
+		c.dc.SetColor(s.stroke)
+		c.dc.SetLineWidth(s.width)
+		c.dc.SetDash(s.dash...)
+		c.dc.StrokePreserve()
+	}
+
+	c.dc.ClearPath()
+}
+
+func (c *pngCanvas) face(f textStyle) font.Face {
+	k := faceKey{size: f.size, italic: f.italic}
+
+	ff, ok := c.faces[k]
+	if !ok {
+		t := goRegular
+		if f.italic {
+			t = goItalic
+		}
+		ff = truetype.NewFace(t, &truetype.Options{Size: f.size})
+		c.faces[k] = ff
+	}
+
+	return ff
+}
+
+func (c *pngCanvas) text(p point, s string, f textStyle, col color.NRGBA) {
+	c.dc.Push()
+	defer c.dc.Pop()
+
+	if f.rotate {
+		c.dc.RotateAbout(math.Pi/2, p.x, p.y)
+	}
+
+	c.dc.SetFontFace(c.face(f))
+	c.dc.SetColor(col)
+	c.dc.DrawString(s, p.x, p.y)
+}
+
+func (c *pngCanvas) textWidth(s string, f textStyle) float64 {
+	c.dc.SetFontFace(c.face(f))
+	w, _ := c.dc.MeasureString(s)
+
+	return w
+}
+
+func (c *pngCanvas) encode(b *bytes.Buffer) error {
+	return c.dc.EncodePNG(b)
+}
@@ -0,0 +1,221 @@
+package ts
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func imagePlot() Plot {
+	var p Plot
+
+	t := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var s Series
+	for i := 0; i < 20; i++ {
+		s.Points = append(s.Points, Point{DateTime: t.Add(time.Duration(i) * time.Hour * 24), Value: float64(i % 5), Error: 0.5})
+	}
+	s.Label = "TN1.TEST1"
+
+	p.AddSeries(s)
+	p.SetTitle("TEST1 - Ωμ тест")
+	p.SetUnit("°C")
+	p.SetYLabel("Test (°C)")
+
+	return p
+}
+
+// dark returns true if there are pixels darker than grey in r.
+func dark(img image.Image, r image.Rectangle) bool {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			if c := color.GrayModel.Convert(img.At(x, y)).(color.Gray); c.Y < 128 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestImagePNG(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := Line.Image(imagePlot(), Layout{}, PNG, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds().Dx() != 800 || img.Bounds().Dy() != 270 {
+		t.Errorf("expected 800x270 image got %v", img.Bounds())
+	}
+
+	if c := color.NRGBAModel.Convert(img.At(795, 5)).(color.NRGBA); c != (color.NRGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("expected white background got %v", c)
+	}
+
+	// the left y axis is a black line along the data.
+	if !dark(img, image.Rect(69, 100, 72, 101)) {
+		t.Error("expected the y axis to be drawn")
+	}
+
+	// the title is centred above the data.
+	if !dark(img, image.Rect(300, 10, 400, 30)) {
+		t.Error("expected the title to be drawn")
+	}
+
+	var l bytes.Buffer
+	if err := Line.Image(imagePlot(), Layout{Width: 50}, PNG, &l); err != ErrLayout {
+		t.Errorf("expected ErrLayout got %v", err)
+	}
+}
+
+func TestImagePDF(t *testing.T) {
+	for _, d := range []interface {
+		Image(Plot, Layout, string, *bytes.Buffer) error
+	}{&Line, &Scatter, &SparkLineAll, &SparkScatterLatest, &SparkLineNone} {
+		var b bytes.Buffer
+
+		if err := d.Image(imagePlot(), Layout{}, PDF, &b); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(b.Bytes(), []byte("%PDF-")) {
+			t.Error("expected PDF header")
+		}
+
+		if !bytes.HasSuffix(bytes.TrimSpace(b.Bytes()), []byte("%%EOF")) {
+			t.Error("expected PDF trailer")
+		}
+	}
+
+	var b bytes.Buffer
+	if err := Line.Image(imagePlot(), Layout{}, "gif", &b); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// the fonts must have glyphs for the unit symbols and non-Latin site and type names.
+func TestImageGlyphs(t *testing.T) {
+	for _, r := range "°±µ²³Ωμσтестāō" {
+		if goRegular.Index(r) == 0 {
+			t.Errorf("no regular glyph for %q", r)
+		}
+		if goItalic.Index(r) == 0 {
+			t.Errorf("no italic glyph for %q", r)
+		}
+	}
+}
+
+const testMap = `<?xml version="1.0"?><svg height="50" width="100" xmlns="http://www.w3.org/2000/svg"><title>Map.</title>
+<defs><filter id="f1"><feGaussianBlur in="SourceGraphic" stdDeviation="4" /></filter></defs>
+<path stroke-width="10" filter="url(#f1)" stroke="azure" d="M 0 0 L 100 0 100 50 Z"/>
+<path fill="whitesmoke" stroke-width="1" stroke="lightslategrey" d="M 0 0 L 60 0 60 50 0 50 Z"/>
+<circle cx="70" cy="10" r="1" stroke="grey" stroke-width="1" fill="lightgrey" />
+<text fill="grey" font-style="italic" x="73" y="15" font-size="11" text-anchor="start">Ōtaki</text>
+<g transform="translate(10,10)"><rect x="-3" y="-3" width="20" height="20" rx="10" ry="10" fill="white"/>
+<rect x="0" y="0" width="10" height="10" fill="red" opacity="0.5"/></g>
+<g id="TN1TEST1"><path d="M40 33 l5 0 l-5 -10 l-5 10 Z" fill="red" opacity="0.5"><desc>TEST1.</desc>
+<set attributeName="opacity" from="0.5" to="1" begin="mouseover" end="mouseout" dur="2s"/></path></g>
+<g id="marker_labels"><text x="95" y="45" font-size="12" visibility="hidden" text-anchor="end">TEST1</text></g>
+</svg>`
+
+func TestRenderMap(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := RenderMap([]byte(testMap), PNG, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 50 {
+		t.Errorf("expected 100x50 image got %v", img.Bounds())
+	}
+
+	in := []struct {
+		id   string
+		x, y int
+		c    color.NRGBA
+	}{
+		{id: "land", x: 50, y: 40, c: color.NRGBA{R: 245, G: 245, B: 245, A: 255}},
+		{id: "inset rect", x: 15, y: 15, c: color.NRGBA{R: 255, G: 128, B: 128, A: 255}},
+		{id: "marker", x: 40, y: 30, c: color.NRGBA{R: 250, G: 122, B: 122, A: 255}},
+		{id: "filtered path left out", x: 90, y: 5, c: color.NRGBA{}},
+		{id: "hidden label left out", x: 90, y: 42, c: color.NRGBA{}},
+	}
+
+	for _, v := range in {
+		c := color.NRGBAModel.Convert(img.At(v.x, v.y)).(color.NRGBA)
+		if d := int(c.R) - int(v.c.R) + int(c.G) - int(v.c.G) + int(c.B) - int(v.c.B); c.A != v.c.A || d > 6 || d < -6 {
+			t.Errorf("%s: expected %v got %v", v.id, v.c, c)
+		}
+	}
+
+	b.Reset()
+	if err := RenderMap([]byte(testMap), PDF, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(b.Bytes(), []byte("%PDF-")) {
+		t.Error("expected PDF header")
+	}
+}
+
+func TestRenderMapErrors(t *testing.T) {
+	in := []struct {
+		id, svg, format string
+	}{
+		{id: "format", svg: testMap, format: "gif"},
+		{id: "no svg", svg: `<?xml version="1.0"?>`, format: PNG},
+		{id: "no size", svg: `<svg xmlns="http://www.w3.org/2000/svg"></svg>`, format: PNG},
+		{id: "before svg", svg: `<g><svg height="10" width="10"></svg></g>`, format: PNG},
+		{id: "curve", svg: `<svg width="10" height="10"><path d="M0 0 C 1 1 2 2 3 3"/></svg>`, format: PDF},
+		{id: "transform", svg: `<svg width="10" height="10"><g transform="rotate(90)"></g></svg>`, format: PNG},
+	}
+
+	for _, v := range in {
+		var b bytes.Buffer
+		if err := RenderMap([]byte(v.svg), v.format, &b); err == nil {
+			t.Errorf("%s: expected error", v.id)
+		}
+	}
+}
+
+func TestPathData(t *testing.T) {
+	in := []struct {
+		d        string
+		expected []subpath
+	}{
+		{d: "M1 2 L3 4", expected: []subpath{{pts: []point{{1, 2}, {3, 4}}}}},
+		{d: "M10 20 l5 0 l-5 -5 Z", expected: []subpath{{pts: []point{{10, 20}, {15, 20}, {10, 15}}, closed: true}}},
+		{d: "M 0 0 L 10 0 10 10 Z M 20 20 L 30 30", expected: []subpath{
+			{pts: []point{{0, 0}, {10, 0}, {10, 10}}, closed: true},
+			{pts: []point{{20, 20}, {30, 30}}},
+		}},
+		{d: "M0,0 10,10", expected: []subpath{{pts: []point{{0, 0}, {10, 10}}}}},
+		{d: "M-1.5e1 2 L-3 -4", expected: []subpath{{pts: []point{{-15, 2}, {-3, -4}}}}},
+	}
+
+	for _, v := range in {
+		p, err := pathData(v.d)
+		if err != nil {
+			t.Errorf("%s: %s", v.d, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(p, v.expected) {
+			t.Errorf("%s: expected %v got %v", v.d, v.expected, p)
+		}
+	}
+}
@@ -4,9 +4,11 @@ Package ts can be used to draw time series plots.
 package ts
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +21,9 @@ type axes struct {
 	XAxisY   int
 	Ylabel   string
 	Title    string
+	Right    bool // there is a right y axis.
+	YRight   []pt
+	YRlabel  string
 }
 
 type plt struct {
@@ -38,6 +43,68 @@ type plt struct {
 	xShift                        int
 	Scheme                        string
 	Fill                          bool
+	Events                        []Event
+	EventPts                      []pt // x position and label for Events in the plot range.
+	Spans                         []Span
+	SpanPts                       []spanPt
+	Layout                        Layout
+	ShowStats                     bool // there is room for the latest, min, and max values.
+	logY                          bool // the y axis has a log scale.
+	dropped                       int  // the number of values <= 0 left off a log y axis.
+	rightUnit                     string
+	KeyX                          int // x position of the key.
+}
+
+/*
+Layout is the size of a plot image and the margins around the data in it, all in px.
+Zero values are set from the layout for the template the plot is drawn with.
+*/
+type Layout struct {
+	Width, Height                                    int // the image size.
+	MarginTop, MarginRight, MarginBottom, MarginLeft int // the space for titles, labels, and the key.
+	FontSize                                         int
+}
+
+// ErrLayout is returned by Draw if the Layout leaves too little room for the data.
+var ErrLayout = errors.New("plot width or height is too small")
+
+// minData is the smallest width and height for the data on a plot.
+const minData = 10
+
+// DataWidth returns the width of the data on the plot.
+func (l Layout) DataWidth() int {
+	return l.Width - l.MarginLeft - l.MarginRight
+}
+
+// DataHeight returns the height of the data on the plot.
+func (l Layout) DataHeight() int {
+	return l.Height - l.MarginTop - l.MarginBottom
+}
+
+// with returns l with any zero values set from d.
+func (l Layout) with(d Layout) (Layout, error) {
+	for _, v := range []struct {
+		v *int
+		d int
+	}{
+		{&l.Width, d.Width},
+		{&l.Height, d.Height},
+		{&l.MarginTop, d.MarginTop},
+		{&l.MarginRight, d.MarginRight},
+		{&l.MarginBottom, d.MarginBottom},
+		{&l.MarginLeft, d.MarginLeft},
+		{&l.FontSize, d.FontSize},
+	} {
+		if *v.v == 0 {
+			*v.v = v.d
+		}
+	}
+
+	if l.DataWidth() < minData || l.DataHeight() < minData {
+		return l, ErrLayout
+	}
+
+	return l, nil
 }
 
 type plotKey struct {
@@ -51,17 +118,22 @@ type Plot struct {
 }
 
 type Point struct {
-	DateTime time.Time
-	Value    float64
-	Error    float64
+	DateTime  time.Time
+	Value     float64
+	Error     float64
+	Hollow    bool // draw the point with a hollow marker e.g., for bad data.
+	Highlight bool // draw the point in the highlight colour e.g., for outliers.
 }
 
 /*
  pt is for points with labels in svg space.
 */
 type pt struct {
-	X, Y, E int
-	L       string
+	X, Y      int
+	E, ED     int // the size of the error above (E) and below (ED) Y.  Only different on a log axis.
+	L         string
+	Hollow    bool
+	Highlight bool
 }
 
 type stddev struct {
@@ -79,11 +151,31 @@ type Series struct {
 	Label  string
 }
 
+// Event is a point in time to mark on a plot e.g., a visual observation.
+type Event struct {
+	DateTime time.Time
+	Label    string
+}
+
+// Span is a time range for an availability timeline.  Gap is true if there is no data for the span.
+type Span struct {
+	Start, End time.Time
+	Gap        bool
+}
+
+// spanPt is a Span in svg space.
+type spanPt struct {
+	X, W int
+	Gap  bool
+	L    string
+}
+
 type data struct {
 	Series    Series
 	Colour    string // svg colour name
 	HasErrors bool
 	Pts       pts
+	Right     bool // the series is drawn on the right y axis.
 }
 
 func (p *Plot) SetTitle(title string) {
@@ -115,6 +207,15 @@ func (p *Plot) SetYRange(r float64) {
 	p.plt.YRange = r
 }
 
+/*
+SetYLog draws the y axis with a log scale.  Values <= 0 are left off the plot and counted in the key.
+The y axis auto ranges on whole decades unless it is set with SetYAxis to values > 0.  SetYRange
+is not used with a log scale.  The right y axis, if there is one, also has a log scale and auto ranges.
+*/
+func (p *Plot) SetYLog() {
+	p.plt.logY = true
+}
+
 func (p *Plot) SetMeanStddev(m, s float64) {
 	p.plt.Stddev.Stddev = s
 	p.plt.Stddev.Mean = m
@@ -125,10 +226,60 @@ func (p *Plot) AddSeries(s Series) {
 	p.plt.Data = append(p.plt.Data, data{Series: s})
 }
 
+/*
+AddRightSeries adds a series that is drawn on a right y axis.  The right y axis auto ranges
+on the data for it and shares the x axis with the rest of the plot.
+*/
+func (p *Plot) AddRightSeries(s Series) {
+	p.plt.Data = append(p.plt.Data, data{Series: s, Right: true})
+}
+
+// SetRightYLabel sets the label for the right y axis.
+func (p *Plot) SetRightYLabel(yLabel string) {
+	p.plt.Axes.YRlabel = yLabel
+}
+
+// SetRightUnit sets the unit for the right y axis.
+func (p *Plot) SetRightUnit(unit string) {
+	p.plt.rightUnit = unit
+}
+
+// AddEvent adds a vertical marker to the plot at the time of e.
+func (p *Plot) AddEvent(e Event) {
+	p.plt.Events = append(p.plt.Events, e)
+}
+
+// AddSpan adds a span to an availability timeline.
+func (p *Plot) AddSpan(s Span) {
+	p.plt.Spans = append(p.plt.Spans, s)
+}
+
 func (p *Plot) SetScheme(s string) {
 	p.plt.Scheme = s
 }
 
+// hasRight returns true if any series are drawn on the right y axis.
+func (p *Plot) hasRight() bool {
+	for _, d := range p.plt.Data {
+		if d.Right {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHollow returns true if any points in the plot should be drawn hollow.
+func (p *Plot) hasHollow() bool {
+	for _, d := range p.plt.Data {
+		for _, point := range d.Series.Points {
+			if point.Hollow {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 var colours = map[string][]string{
 	"web": {
 		"darkcyan",
@@ -152,6 +303,9 @@ var colours = map[string][]string{
 
 var numColours = len(colours["web"]) - 1
 
+// highlight is the colour for highlighted points.  It is not used for series.
+const highlight = "crimson"
+
 // order by labels to keep the colours the same
 // for each label between redraws of the plot
 // Note: Scheme must being set before calling this
@@ -183,41 +337,203 @@ func (p *Plot) setColours() {
 // Note: Scheme must being set before calling this
 func (p *Plot) setKey() {
 	labels := make(map[string]string)
+	right := make(map[string]bool)
 	var keys []string
 
 	for _, d := range p.plt.Data {
 		labels[d.Series.Label] = d.Colour
+		right[d.Series.Label] = d.Right
 		keys = append(keys, d.Series.Label)
 	}
 
 	sort.Strings(keys)
 
+	line := p.plt.Layout.FontSize
+
 	y := 0
 	for _, k := range keys {
 		pk := plotKey{Marker: pt{Y: y, L: labels[k]}, Fill: p.plt.Fill}
 		str := strings.Fields(k)
 		pk.Text = append(pk.Text, pt{L: str[0], X: 6, Y: y})
-		y = y + 12
+		y = y + line
 		for _, s := range str[1:] {
 			pk.Text = append(pk.Text, pt{L: s, X: 9, Y: y})
-			y = y + 12
+			y = y + line
+		}
+
+		if p.plt.Axes.Right {
+			a, u := "left axis", p.plt.Unit
+			if right[k] {
+				a, u = "right axis", p.plt.rightUnit
+			}
+			if u != "" {
+				a = a + " (" + u + ")"
+			}
+			pk.Text = append(pk.Text, pt{L: a, X: 9, Y: y})
+			y = y + line
 		}
 
 		p.plt.PlotKey = append(p.plt.PlotKey, pk)
 		y = y + 5
 	}
 
+	var n int
+	for _, d := range p.plt.Data {
+		for _, point := range d.Series.Points {
+			if point.Highlight {
+				n++
+			}
+		}
+	}
+
+	if n > 0 {
+		p.plt.PlotKey = append(p.plt.PlotKey, plotKey{
+			Marker: pt{Y: y, L: highlight},
+			Text:   []pt{{L: fmt.Sprintf("outliers: %d", n), X: 6, Y: y}},
+			Fill:   true,
+		})
+		y = y + line + 5
+	}
+
+	if p.plt.dropped > 0 {
+		// no marker for values left off a log scale.
+		p.plt.PlotKey = append(p.plt.PlotKey, plotKey{Text: []pt{
+			{X: 0, Y: y, L: fmt.Sprintf("not shown: %d", p.plt.dropped)},
+			{X: 0, Y: y + line, L: "(zero or less)"},
+		}, Fill: p.plt.Fill})
+		y = y + (line * 2) + 5
+	}
+
 	if p.plt.Stddev.Show {
 		// no marker for stddev
 		y = y + 5
 		p.plt.PlotKey = append(p.plt.PlotKey, plotKey{Text: []pt{
 			{X: 0, Y: y, L: fmt.Sprintf("mean: %.3f", p.plt.Stddev.Mean)},
-			{X: 0, Y: y + 13, L: fmt.Sprintf("stddev: %.3f", p.plt.Stddev.Stddev)},
+			{X: 0, Y: y + line + 1, L: fmt.Sprintf("stddev: %.3f", p.plt.Stddev.Stddev)},
 		}, Fill: p.plt.Fill})
 	}
 }
 
+/*
+splitRight moves the data for the right y axis from p to a plot of its own with the same layout,
+x axis, and y scale as p.  The x axis is set from all the data if it has not been set.  Returns nil if
+there is no data for the right y axis.  Colours should be set before calling splitRight().
+*/
+func (p *Plot) splitRight() *Plot {
+	var r Plot
+	var left []data
+
+	for _, d := range p.plt.Data {
+		if d.Right {
+			r.plt.Data = append(r.plt.Data, d)
+		} else {
+			left = append(left, d)
+		}
+	}
+
+	if len(r.plt.Data) == 0 {
+		return nil
+	}
+
+	if (p.plt.XMin == time.Time{} && p.plt.XMax == time.Time{}) {
+		first := time.Now().UTC()
+		var last time.Time
+
+		for _, d := range p.plt.Data {
+			if n := len(d.Series.Points); n > 0 {
+				if d.Series.Points[0].DateTime.Before(first) {
+					first = d.Series.Points[0].DateTime
+				}
+				if d.Series.Points[n-1].DateTime.After(last) {
+					last = d.Series.Points[n-1].DateTime
+				}
+			}
+		}
+
+		if !last.IsZero() {
+			p.SetXAxis(first, last)
+		}
+	}
+
+	p.plt.Data = left
+
+	r.plt.XMin, r.plt.XMax = p.plt.XMin, p.plt.XMax
+	r.plt.Layout = p.plt.Layout
+	r.plt.width, r.plt.height = p.plt.width, p.plt.height
+	r.plt.logY = p.plt.logY
+
+	return &r
+}
+
+// addRight adds the data and y grid from r, which has been scaled for the right y axis, to p.
+func (p *Plot) addRight(r *Plot) {
+	p.plt.Data = append(p.plt.Data, r.plt.Data...)
+	p.plt.Axes.Right = true
+	p.plt.Axes.YRight = r.plt.Axes.Y
+	p.plt.RangeAlert = p.plt.RangeAlert || r.plt.RangeAlert
+	p.plt.dropped += r.plt.dropped
+}
+
+/*
+dropNonPositive removes values <= 0 from the data for a log y axis and counts them.  The data
+is copied so the series added to p are not changed.
+*/
+func (p *Plot) dropNonPositive() {
+	d := make([]data, len(p.plt.Data))
+
+	for i := range p.plt.Data {
+		d[i] = p.plt.Data[i]
+		d[i].Series.Points = make([]Point, 0, len(p.plt.Data[i].Series.Points))
+
+		for _, point := range p.plt.Data[i].Series.Points {
+			if point.Value > 0 {
+				d[i].Series.Points = append(d[i].Series.Points, point)
+			} else {
+				p.plt.dropped++
+			}
+		}
+	}
+
+	p.plt.Data = d
+}
+
+// y returns the y position in svg space for the value v.  Values <= 0 on a log axis are at the bottom of the plot.
+func (p *Plot) y(v float64) int {
+	if !p.plt.logY {
+		return p.plt.height - int(((v-p.plt.YMin)*p.plt.dy)+0.5)
+	}
+
+	if v <= 0 {
+		return p.plt.height
+	}
+
+	return p.plt.height - int(((math.Log10(v)-math.Log10(p.plt.YMin))*p.plt.dy)+0.5)
+}
+
+/*
+e returns the sizes in svg space of the error e above and below the value v.  On a log axis
+these are from v+e and v-e so they are not the same.  If v-e is zero or less the error below
+v goes to the bottom of the plot.
+*/
+func (p *Plot) e(v, e float64) (up, down int) {
+	if !p.plt.logY {
+		return int(e * p.plt.dy), int(e * p.plt.dy)
+	}
+
+	up = int((math.Log10(v+e) - math.Log10(v)) * p.plt.dy)
+
+	if v-e <= 0 {
+		return up, p.plt.height - p.y(v)
+	}
+
+	return up, int((math.Log10(v) - math.Log10(v-e)) * p.plt.dy)
+}
+
 func (p *Plot) scaleData() {
+	if p.plt.logY {
+		p.dropNonPositive()
+	}
+
 	p.plt.Max.Value = math.MaxFloat64 * -1.0
 	p.plt.Min.Value = math.MaxFloat64
 	p.plt.First.DateTime = time.Now().UTC()
@@ -259,6 +575,22 @@ func (p *Plot) scaleData() {
 	}
 
 	switch {
+	case p.plt.logY:
+		if p.plt.YMin <= 0 || p.plt.YMax <= p.plt.YMin {
+			// auto range on whole decades.
+			p.plt.YMin, p.plt.YMax = 1, 10
+
+			if p.plt.Max.Value >= p.plt.Min.Value {
+				p.plt.YMin = math.Pow(10, math.Floor(math.Log10(p.plt.Min.Value)))
+				p.plt.YMax = math.Pow(10, math.Ceil(math.Log10(p.plt.Max.Value)))
+			}
+
+			if p.plt.YMax <= p.plt.YMin {
+				p.plt.YMax = p.plt.YMin * 10
+			}
+		}
+
+		p.plt.dy = float64(p.plt.height) / (math.Log10(p.plt.YMax) - math.Log10(p.plt.YMin))
 	case p.plt.YMin != 0 || p.plt.YMax != 0:
 		p.plt.dy = float64(p.plt.height) / math.Abs(p.plt.YMax-p.plt.YMin)
 	case p.plt.YRange > 0.0:
@@ -286,28 +618,39 @@ func (p *Plot) scaleData() {
 
 		for j := range p.plt.Data[i].Series.Points {
 			p.plt.Data[i].Pts[j] = pt{
-				X: int((p.plt.Data[i].Series.Points[j].DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
-				Y: p.plt.height - int(((p.plt.Data[i].Series.Points[j].Value-p.plt.YMin)*p.plt.dy)+0.5),
-				E: int(p.plt.Data[i].Series.Points[j].Error * p.plt.dy),
+				X:         int((p.plt.Data[i].Series.Points[j].DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
+				Y:         p.y(p.plt.Data[i].Series.Points[j].Value),
+				Hollow:    p.plt.Data[i].Series.Points[j].Hollow,
+				Highlight: p.plt.Data[i].Series.Points[j].Highlight,
 			}
+			p.plt.Data[i].Pts[j].E, p.plt.Data[i].Pts[j].ED = p.e(p.plt.Data[i].Series.Points[j].Value, p.plt.Data[i].Series.Points[j].Error)
 		}
 	}
 
 	p.plt.MinPt = pt{
 		X: int((p.plt.Min.DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
-		Y: p.plt.height - int(((p.plt.Min.Value-p.plt.YMin)*p.plt.dy)+0.5),
+		Y: p.y(p.plt.Min.Value),
 	}
 	p.plt.MaxPt = pt{
 		X: int((p.plt.Max.DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
-		Y: p.plt.height - int(((p.plt.Max.Value-p.plt.YMin)*p.plt.dy)+0.5),
+		Y: p.y(p.plt.Max.Value),
 	}
 	p.plt.FirstPt = pt{
 		X: int((p.plt.First.DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
-		Y: p.plt.height - int(((p.plt.First.Value-p.plt.YMin)*p.plt.dy)+0.5),
+		Y: p.y(p.plt.First.Value),
 	}
 	p.plt.LastPt = pt{
 		X: int((p.plt.Last.DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift,
-		Y: p.plt.height - int(((p.plt.Last.Value-p.plt.YMin)*p.plt.dy)+0.5),
+		Y: p.y(p.plt.Last.Value),
+	}
+
+	p.plt.EventPts = make([]pt, 0)
+
+	for _, e := range p.plt.Events {
+		x := int((e.DateTime.Sub(p.plt.First.DateTime).Seconds()*p.plt.dx)+0.5) + p.plt.xShift
+		if x >= 0 && x <= p.plt.width {
+			p.plt.EventPts = append(p.plt.EventPts, pt{X: x, L: e.Label})
+		}
 	}
 
 	if p.plt.MinPt.Y > p.plt.height {
@@ -317,10 +660,15 @@ func (p *Plot) scaleData() {
 		p.plt.RangeAlert = true
 	}
 
-	if p.plt.Stddev.Show {
-		p.plt.Stddev.M = p.plt.height - int(((p.plt.Stddev.Mean-p.plt.YMin)*p.plt.dy)+0.5)
+	switch {
+	case p.plt.Stddev.Show && p.plt.logY:
+		p.plt.Stddev.M = p.y(p.plt.Stddev.Mean)
+		p.plt.Stddev.Y = p.y(p.plt.Stddev.Mean + p.plt.Stddev.Stddev)
+		p.plt.Stddev.H = p.y(p.plt.Stddev.Mean-p.plt.Stddev.Stddev) - p.plt.Stddev.Y
+	case p.plt.Stddev.Show:
+		p.plt.Stddev.M = p.y(p.plt.Stddev.Mean)
 		p.plt.Stddev.H = int((p.plt.Stddev.Stddev * 2 * p.plt.dy) + 0.5)
-		p.plt.Stddev.Y = p.plt.height - int(((p.plt.Stddev.Mean+p.plt.Stddev.Stddev-p.plt.YMin)*p.plt.dy)+0.5)
+		p.plt.Stddev.Y = p.y(p.plt.Stddev.Mean + p.plt.Stddev.Stddev)
 	}
 
 	return
@@ -331,6 +679,12 @@ setAxes builds x and y grids.  Major ticks are labelled, minor ticks are not.
 scaleData() should be called before setAxes()
 */
 func (p *Plot) setAxes() {
+	if p.plt.logY {
+		p.setLogYAxis()
+		p.setXAxis()
+		return
+	}
+
 	// y axis
 	p.plt.Axes.Y = make([]pt, 0)
 
@@ -344,6 +698,15 @@ func (p *Plot) setAxes() {
 		ma = ma / 2
 	}
 
+	// label fewer ticks, in steps of 1, 2, and 5, if the labels would overlap.
+	for i := 0; ma*p.plt.dy < 1.25*float64(p.plt.Layout.FontSize); i++ {
+		if i%3 == 1 {
+			ma = ma * 2.5
+		} else {
+			ma = ma * 2
+		}
+	}
+
 	// work through a range of values larger than the yrange in even spaced increments.
 	max := (math.Floor(p.plt.YMax/ma) + 1) * ma
 	min := (math.Floor(p.plt.YMin/ma) - 1) * ma
@@ -382,49 +745,74 @@ func (p *Plot) setAxes() {
 		}
 	}
 
-	// x axis
-	p.plt.Axes.X = make([]pt, 0)
+	p.setXAxis()
+}
 
-	numYear := (p.plt.XMax.Year() - p.plt.XMin.Year())
-	// approx number of months
-	numMonth := int(p.plt.XMax.Sub(p.plt.XMin).Hours() / 24 / 28)
+/*
+setLogYAxis builds the y grid for a log scale.  Decades are labelled, fewer of them if the labels
+would overlap.  Ticks at 2 to 9 times each decade are added if there is room and 2 and 5 times
+are labelled if there is room for them as well.
+*/
+func (p *Plot) setLogYAxis() {
+	p.plt.Axes.Y = make([]pt, 0)
 
-	labelYear := true
-	showMonth := true
+	fs := 1.25 * float64(p.plt.Layout.FontSize)
 
-	switch {
-	case numYear == 0:
-	case numMonth == 0:
-	case p.plt.width/numYear < 60:
-		labelYear = false
-		showMonth = false
-	case p.plt.width/numMonth < 60:
-		showMonth = false
-	}
-
-	for i := p.plt.XMin.Year() + 1; i <= p.plt.XMax.Year(); i++ {
-		t := time.Date(i, 1, 1, 0, 0, 0, 0, time.UTC)
-		v := pt{X: int((t.Sub(p.plt.XMin).Seconds() * p.plt.dx) + 0.5)}
-		if (labelYear || i%5 == 0) && !showMonth {
-			v.L = fmt.Sprintf("%d", int(t.Year()))
+	// label every n decades.
+	n := 1
+	for i := 0; float64(n)*p.plt.dy < fs; i++ {
+		if i%3 == 1 {
+			n = n * 5 / 2
+		} else {
+			n = n * 2
 		}
-
-		p.plt.Axes.X = append(p.plt.Axes.X, v)
 	}
 
-	if showMonth {
+	min := int(math.Floor(math.Log10(p.plt.YMin)))
+	max := int(math.Ceil(math.Log10(p.plt.YMax)))
 
-		for i := p.plt.XMin.Year() - 1; i <= p.plt.XMax.Year()+1; i++ {
-			for j := 1; j <= 12; j++ {
-				t := time.Date(i, time.Month(j), 1, 0, 0, 0, 0, time.UTC)
-				if t.Before(p.plt.XMax) && p.plt.XMin.Before(t) {
-					v := pt{X: int((t.Sub(p.plt.XMin).Seconds() * p.plt.dx) + 0.5)}
-					if showMonth || int(t.Month())%6 == 1 {
-						v.L = fmt.Sprintf("%d-%02d", t.Year(), t.Month())
-					}
-					p.plt.Axes.X = append(p.plt.Axes.X, v)
-				}
+	// there is room for minor ticks if 9 and 10 times a decade are 2 px apart.
+	minor := math.Log10(10.0/9.0)*p.plt.dy >= 2
+
+	for k := min; k <= max; k++ {
+		for m := 1; m < 10; m++ {
+			v := logValue(m, k)
+			if !inRange(v, p.plt.YMin, p.plt.YMax) {
+				continue
+			}
+
+			t := pt{Y: p.y(v)}
+
+			switch {
+			case m == 1 && k%n == 0:
+				t.L = strconv.FormatFloat(v, 'g', -1, 64)
+			case m == 1:
+			case !minor:
+				continue
+			case (m == 2 || m == 5) && math.Log10(2)*p.plt.dy >= fs:
+				t.L = strconv.FormatFloat(v, 'g', -1, 64)
 			}
+
+			p.plt.Axes.Y = append(p.plt.Axes.Y, t)
 		}
 	}
 }
+
+// logValue returns m*10^k without rounding errors in the label.
+func logValue(m, k int) float64 {
+	v, _ := strconv.ParseFloat(fmt.Sprintf("%de%d", m, k), 64)
+	return v
+}
+
+// inRange returns true if v is in the range min to max, allowing for rounding.
+func inRange(v, min, max float64) bool {
+	return v >= min*(1-1e-9) && v <= max*(1+1e-9)
+}
+
+/*
+setXAxis builds the x grid.  Major ticks are labelled, minor ticks are not.
+p.plt.width must be set before calling setXAxis()
+*/
+func (p *Plot) setXAxis() {
+	p.plt.Axes.X = timeAxis(p.plt.XMin, p.plt.XMax, p.plt.width, p.plt.Layout.FontSize)
+}
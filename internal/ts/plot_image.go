@@ -0,0 +1,239 @@
+package ts
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*
+Image draws p to b as a PNG or PDF image that looks the same as the SVG from Draw.
+Zero values in l are set from the default layout for the template.
+Returns ErrLayout if l leaves too little room to draw the data.
+*/
+func (s *SVGPlot) Image(p Plot, l Layout, format string, b *bytes.Buffer) error {
+	if err := s.setup(&p, l); err != nil {
+		return err
+	}
+
+	c, err := newCanvas(format, p.plt.Layout.Width, p.plt.Layout.Height)
+	if err != nil {
+		return err
+	}
+
+	p.plt.drawPlot(c, s.data, s.keyMarker)
+
+	return c.encode(b)
+}
+
+// drawPlot draws p on c as for plotBaseTemplate.
+func (p *plt) drawPlot(c canvas, data func(drawing, *plt), keyMarker func(drawing, plotKey)) {
+	l := p.Layout
+	w, h, fs := l.DataWidth(), l.DataHeight(), l.FontSize
+
+	text := textStyle{size: float64(fs)}
+	grey, black := colour("darkslategrey"), colour("black")
+	axis := stroke("black", 1)
+
+	img := drawing{c: c}
+	img.rect(0, 0, l.Width, l.Height, colour("white"))
+
+	d := img.at(l.MarginLeft, l.MarginTop)
+
+	if p.RangeAlert {
+		d.rect(0, 0, w, h, colour("mistyrose"))
+	}
+
+	d.line(axis, 0, 0, 0, h)
+	d.line(axis, 0, h, w, h)
+
+	// grid, axes, title
+	for _, x := range p.Axes.X {
+		if x.L != "" {
+			d.line(stroke("paleturquoise", 2), x.X, 0, x.X, h)
+			d.line(axis, x.X, h-4, x.X, h+4)
+			d.text(x.X, h+fs+8, textStyle{size: text.size, anchor: middle}, run{s: x.L, c: grey})
+		} else {
+			d.line(axis, x.X, h-2, x.X, h+2)
+		}
+	}
+
+	for _, y := range p.Axes.Y {
+		if y.L != "" {
+			d.line(stroke("paleturquoise", 1), 0, y.Y, w, y.Y)
+			d.line(axis, -4, y.Y, 4, y.Y)
+			d.text(-7, y.Y, textStyle{size: text.size, anchor: end, centre: true}, run{s: y.L, c: grey})
+		} else {
+			d.line(axis, -2, y.Y, 2, y.Y)
+		}
+	}
+
+	if p.Axes.Right {
+		d.line(axis, w, 0, w, h)
+		for _, y := range p.Axes.YRight {
+			if y.L != "" {
+				d.line(axis, w-4, y.Y, w+4, y.Y)
+				d.text(w+7, y.Y, textStyle{size: text.size, centre: true}, run{s: y.L, c: grey})
+			} else {
+				d.line(axis, w-2, y.Y, w+2, y.Y)
+			}
+		}
+		d.text(w+55, h/2, textStyle{size: text.size, anchor: middle, rotate: true}, run{s: p.Axes.YRlabel, c: black})
+	}
+
+	if p.Axes.XAxisVis {
+		zero := stroke("darkslategrey", 1)
+		d.line(zero, -5, p.Axes.XAxisY, w, p.Axes.XAxisY)
+		for _, x := range p.Axes.X {
+			t := 2
+			if x.L != "" {
+				t = 4
+			}
+			d.line(zero, x.X, p.Axes.XAxisY-t, x.X, p.Axes.XAxisY+t)
+		}
+		d.line(zero, 0, 0, 0, h+4)
+	}
+
+	d.text(w/2, -(fs + 3), textStyle{size: float64(fs + 4), anchor: middle}, run{s: p.Axes.Title, c: black})
+	d.text(-(l.MarginLeft - 10), h/2, textStyle{size: text.size, anchor: middle, rotate: true}, run{s: p.Axes.Ylabel, c: black})
+	d.text(w/2, h+fs+fs+14, textStyle{size: float64(fs + 2), anchor: middle}, run{s: "Date", c: black})
+
+	p.drawStddev(d)
+
+	event := stroke("darkorange", 1)
+	event.dash = []float64{4, 2}
+	for _, e := range p.EventPts {
+		d.line(event, e.X, 0, e.X, h)
+		d.at(e.X, 0).polygon(paint{fill: colour("darkorange")}, -4, -7, 4, -7, 0, 0)
+	}
+
+	data(d, p)
+
+	d.dot(p.LastPt.X, p.LastPt.Y, 4, marker("red", p.Fill))
+	d.dot(p.MinPt.X, p.MinPt.Y, 4, marker("blue", p.Fill))
+	d.dot(p.MaxPt.X, p.MaxPt.Y, 4, marker("blue", p.Fill))
+
+	k := img.at(p.KeyX, l.MarginTop+10)
+	for _, pk := range p.PlotKey {
+		if pk.Marker.L != "" {
+			keyMarker(k, pk)
+		}
+		for _, t := range pk.Text {
+			k.text(t.X, t.Y, textStyle{size: text.size, centre: true}, run{s: t.L, c: grey})
+		}
+	}
+
+	if p.ShowStats && !p.Last.DateTime.IsZero() {
+		img.text(l.MarginLeft+w, l.Height-2, textStyle{size: text.size, italic: true, anchor: end}, p.stats("darkslategrey")...)
+	}
+
+	img.text(5, l.Height-2, text, run{s: "CC BY 3.0 NZ GNS Science", c: grey})
+}
+
+// marker returns the paint for the circles marking the latest, min, and max values.
+func marker(c string, fill bool) paint {
+	s := stroke(c, 1)
+	if fill {
+		s.fill = s.stroke
+	}
+	return s
+}
+
+func (p *plt) drawStddev(d drawing) {
+	if !p.Stddev.Show {
+		return
+	}
+
+	w := p.Layout.DataWidth()
+
+	d.rect(0, p.Stddev.Y, w, p.Stddev.H, fade(colour("gainsboro"), 0.5))
+	d.line(stroke("gainsboro", 1), 0, p.Stddev.M, w, p.Stddev.M)
+}
+
+// stats returns the text for the latest, min, and max values with the rest of the text in c.
+func (p *plt) stats(c string) []run {
+	t := colour(c)
+
+	return []run{
+		{s: "latest: ", c: t},
+		{s: fmt.Sprintf("%.2f %s", p.Last.Value, p.Unit), c: colour("red")},
+		{s: fmt.Sprintf(" (%s) min: ", date(p.Last.DateTime)), c: t},
+		{s: fmt.Sprintf("%.2f", p.Min.Value), c: colour("blue")},
+		{s: fmt.Sprintf(" (%s) max: ", date(p.Min.DateTime)), c: t},
+		{s: fmt.Sprintf("%.2f", p.Max.Value), c: colour("blue")},
+		{s: fmt.Sprintf(" (%s)", date(p.Max.DateTime)), c: t},
+	}
+}
+
+// lineData draws as for the data template in plotLineTemplate.
+func lineData(d drawing, p *plt) {
+	hl := colour(highlight)
+
+	for _, v := range p.Data {
+		c := colour(v.Colour)
+
+		if v.HasErrors {
+			e := fade(c, 0.25)
+			d.polygon(paint{fill: e, stroke: e, width: 1}, v.Pts.errorPoly()...)
+		}
+
+		d.line(paint{stroke: c, width: 1}, v.Pts.line()...)
+
+		for _, pt := range v.Pts {
+			if pt.Hollow {
+				d.dot(pt.X, pt.Y, 3, paint{fill: colour("white"), stroke: c, width: 1})
+			}
+		}
+
+		for _, pt := range v.Pts {
+			if pt.Highlight {
+				s := paint{fill: hl, stroke: hl, width: 1}
+				if pt.Hollow {
+					s.fill = colour("white")
+				}
+				d.dot(pt.X, pt.Y, 3, s)
+			}
+		}
+	}
+}
+
+func lineKeyMarker(d drawing, k plotKey) {
+	d.line(paint{stroke: colour(k.Marker.L), width: 3}, -3, k.Marker.Y, 3, k.Marker.Y)
+}
+
+// scatterData draws as for the data template in plotScatterTemplate.
+func scatterData(d drawing, p *plt) {
+	hl := colour(highlight)
+
+	for _, v := range p.Data {
+		c := colour(v.Colour)
+
+		if v.HasErrors {
+			e := paint{stroke: fade(c, 0.25), width: 1}
+			for _, pt := range v.Pts {
+				d.line(e, pt.X, pt.Y+pt.ED, pt.X, pt.Y-pt.E)
+			}
+		}
+
+		for _, pt := range v.Pts {
+			s := paint{stroke: c, width: 1}
+
+			switch {
+			case pt.Hollow:
+			case pt.Highlight:
+				s.fill = hl
+			case p.Fill:
+				s.fill = c
+			}
+
+			if pt.Highlight {
+				s.stroke = hl
+			}
+
+			d.dot(pt.X, pt.Y, 2, s)
+		}
+	}
+}
+
+func scatterKeyMarker(d drawing, k plotKey) {
+	d.dot(k.Marker.X, k.Marker.Y, 2, marker(k.Marker.L, k.Fill))
+}
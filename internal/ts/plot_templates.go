@@ -9,63 +9,185 @@ import (
 )
 
 var funcMap = template.FuncMap{
-	"date": func(t time.Time) string {
-		return strings.Split(t.Format(time.RFC3339), "T")[0]
+	"date": date,
+	"highlight": func() string {
+		return highlight
+	},
+	"add": func(a int, b ...int) int {
+		for _, v := range b {
+			a += v
+		}
+		return a
+	},
+	"sub": func(a, b int) int {
+		return a - b
+	},
+	"half": func(a int) int {
+		return a / 2
 	},
 }
 
+// date returns the date part of t.
+func date(t time.Time) string {
+	return strings.Split(t.Format(time.RFC3339), "T")[0]
+}
+
 type SVGPlot struct {
-	template      *template.Template // the name for the template must be "plot"
-	width, height int                // for the data on the plot, not the overall size.
+	template *template.Template // the name for the template must be "plot"
+	layout   Layout             // the default layout.
+	// data and keyMarker draw the same as the templates with the same names for Image.
+	data      func(d drawing, p *plt)
+	keyMarker func(d drawing, k plotKey)
+}
+
+// rightAxisWidth is the extra right margin in px for the labels on a right y axis.
+const rightAxisWidth = 65
+
+/*
+Draw draws p to b.  Zero values in l are set from the default layout for the template.
+The default right margin is wider if there is a right y axis.
+Returns ErrLayout if l leaves too little room to draw the data.
+*/
+func (s *SVGPlot) Draw(p Plot, l Layout, b *bytes.Buffer) error {
+	if err := s.setup(&p, l); err != nil {
+		return err
+	}
+
+	return s.template.ExecuteTemplate(b, "plot", p.plt)
 }
 
-func (s *SVGPlot) Draw(p Plot, b *bytes.Buffer) error {
-	p.plt.width = s.width
-	p.plt.height = s.height
+// setup sets the layout, colours, axes, and key for drawing p.
+func (s *SVGPlot) setup(p *Plot, l Layout) error {
+	var err error
+
+	d := s.layout
+	if p.hasRight() {
+		d.MarginRight += rightAxisWidth
+	}
+
+	if p.plt.Layout, err = l.with(d); err != nil {
+		return err
+	}
+
+	p.plt.width = p.plt.Layout.DataWidth()
+	p.plt.height = p.plt.Layout.DataHeight()
+
+	p.plt.KeyX = p.plt.Layout.MarginLeft + p.plt.width + 20
+	if p.hasRight() {
+		p.plt.KeyX += rightAxisWidth
+	}
+
+	// the latest, min, and max values only fit beside the license on wide plots.
+	p.plt.ShowStats = p.plt.Layout.MarginLeft+p.plt.width >= p.plt.Layout.FontSize*48
 
 	// Force default scheme to web
 	if p.plt.Scheme == "" || colours[p.plt.Scheme] == nil {
 		p.plt.Scheme = "web"
 	}
 
-	if p.plt.Scheme != "web" {
+	// fill markers so that hollow markers stand out.
+	if p.plt.Scheme != "web" || p.hasHollow() {
 		p.plt.Fill = true
 	}
 
 	p.setColours()
+
+	// the right y axis is scaled on its own data.
+	r := p.splitRight()
+
 	p.scaleData()
 	p.setAxes()
+
+	if r != nil {
+		r.scaleData()
+		r.setAxes()
+		p.addRight(r)
+	}
+
 	p.setKey()
 
-	return s.template.ExecuteTemplate(b, "plot", p.plt)
+	return nil
+}
+
+// plotLayout is the default layout for plots, with the data 600x170 px.
+var plotLayout = Layout{
+	Width:        800,
+	Height:       270,
+	MarginTop:    40,
+	MarginRight:  130,
+	MarginBottom: 60,
+	MarginLeft:   70,
+	FontSize:     12,
 }
 
 var Line = SVGPlot{
-	template: template.Must(template.New("plot").Funcs(funcMap).Parse(plotBaseTemplate + plotLineTemplate)),
-	width:    600,
-	height:   170,
+	template:  template.Must(template.New("plot").Funcs(funcMap).Parse(plotBaseTemplate + plotLineTemplate)),
+	layout:    plotLayout,
+	data:      lineData,
+	keyMarker: lineKeyMarker,
 }
 
 var Scatter = SVGPlot{
-	template: template.Must(template.New("plot").Funcs(funcMap).Parse(plotBaseTemplate + plotScatterTemplate)),
-	width:    600,
-	height:   170,
+	template:  template.Must(template.New("plot").Funcs(funcMap).Parse(plotBaseTemplate + plotScatterTemplate)),
+	layout:    plotLayout,
+	data:      scatterData,
+	keyMarker: scatterKeyMarker,
 }
 
 func (p pt) ErrorBar() string {
-	return fmt.Sprintf("%d,%d %d,%d", p.X, p.Y+p.E, p.X, p.Y-p.E)
+	return fmt.Sprintf("%d,%d %d,%d", p.X, p.Y+p.ED, p.X, p.Y-p.E)
 }
 
 func (p pts) ErrorPoly() string {
-	var b bytes.Buffer
+	return svgPoints(p.errorPoly())
+}
+
+// errorPoly returns the x, y pairs for a polygon around the errors for the points that are not hollow.
+func (p pts) errorPoly() []int {
+	var xy []int
 
 	// the first half of the error polygon - left to right and above the value.
 	for i := range p {
-		b.WriteString(fmt.Sprintf("%d,%d ", p[i].X, p[i].Y-p[i].E))
+		if p[i].Hollow {
+			continue
+		}
+		xy = append(xy, p[i].X, p[i].Y-p[i].E)
 	}
 	// the second half of the error polygon - right to left and below the value
 	for i := len(p) - 1; i >= 0; i-- {
-		b.WriteString(fmt.Sprintf("%d,%d ", p[i].X, p[i].Y+p[i].E))
+		if p[i].Hollow {
+			continue
+		}
+		xy = append(xy, p[i].X, p[i].Y+p[i].ED)
+	}
+
+	return xy
+}
+
+// Line returns the svg points for a line through the points that are not hollow.
+func (p pts) Line() string {
+	return svgPoints(p.line())
+}
+
+// line returns the x, y pairs for a line through the points that are not hollow.
+func (p pts) line() []int {
+	var xy []int
+
+	for i := range p {
+		if !p[i].Hollow {
+			xy = append(xy, p[i].X, p[i].Y)
+		}
+	}
+
+	return xy
+}
+
+// svgPoints formats the x, y pairs in xy for an svg points attribute.
+func svgPoints(xy []int) string {
+	var b bytes.Buffer
+
+	for i := 0; i+1 < len(xy); i += 2 {
+		b.WriteString(fmt.Sprintf("%d,%d ", xy[i], xy[i+1]))
 	}
 
 	return b.String()
@@ -76,30 +198,30 @@ templates are composed.  Any template using base must also define
 'data' for plotting the template and 'keyMarker'.
 */
 const plotBaseTemplate = `<?xml version="1.0"?>
-<svg width="800" height="270" xmlns="http://www.w3.org/2000/svg" font-family="Arial, sans-serif" font-size="12px" fill="darkslategrey">
-<rect x="0" y="0" width="800" height="270" fill="white"/>
-<g transform="translate(70,40)">
-{{if .RangeAlert}}<rect x="0" y="0" width="600" height="170" fill="mistyrose"/>{{end}}
+{{$w := .Layout.DataWidth}}{{$h := .Layout.DataHeight}}{{$fs := .Layout.FontSize}}
+<svg width="{{.Layout.Width}}" height="{{.Layout.Height}}" xmlns="http://www.w3.org/2000/svg" font-family="Arial, sans-serif" font-size="{{$fs}}px" fill="darkslategrey">
+<rect x="0" y="0" width="{{.Layout.Width}}" height="{{.Layout.Height}}" fill="white"/>
+<g transform="translate({{.Layout.MarginLeft}},{{.Layout.MarginTop}})">
+{{if .RangeAlert}}<rect x="0" y="0" width="{{$w}}" height="{{$h}}" fill="mistyrose"/>{{end}}
 
 {{/* axis */}}
-<polyline fill="none" stroke="black" stroke-width="1" points="0,0 0,170"/>
-<polyline fill="none" stroke="black" stroke-width="1" points="0,170 600,170"/>
+<polyline fill="none" stroke="black" stroke-width="1" points="0,0 0,{{$h}}"/>
+<polyline fill="none" stroke="black" stroke-width="1" points="0,{{$h}} {{$w}},{{$h}}"/>
 
 {{/* Grid, axes, title */}}
 {{range .Axes.X}}
 {{if .L}}
-<polyline fill="none" stroke="paleturquoise" stroke-width="2" points="{{.X}},0 {{.X}},170"/>
-<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},166 {{.X}},174"/>
-<text x="{{.X}}" y="190" text-anchor="middle">{{.L}}</text>
+<polyline fill="none" stroke="paleturquoise" stroke-width="2" points="{{.X}},0 {{.X}},{{$h}}"/>
+<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},{{sub $h 4}} {{.X}},{{add $h 4}}"/>
+<text x="{{.X}}" y="{{add $h $fs 8}}" text-anchor="middle">{{.L}}</text>
 {{else}}
-<polyline fill="none" stroke="paleturquoise" stroke-width="2" points="{{.X}},0 {{.X}},170"/>
-<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},168 {{.X}},172"/>
+<polyline fill="none" stroke="black" stroke-width="1" points="{{.X}},{{sub $h 2}} {{.X}},{{add $h 2}}"/>
 {{end}}
 {{end}}
 
 {{range .Axes.Y}}
 {{if .L}}
-<polyline fill="none" stroke="paleturquoise" stroke-width="1" points="0,{{.Y}} 600,{{.Y}}"/>
+<polyline fill="none" stroke="paleturquoise" stroke-width="1" points="0,{{.Y}} {{$w}},{{.Y}}"/>
 <polyline fill="none" stroke="black" stroke-width="1" points="-4,{{.Y}} 4,{{.Y}}"/>
 <text x="-7" y="{{.Y}}" text-anchor="end" dominant-baseline="middle">{{.L}}</text>
 {{else}}
@@ -107,8 +229,21 @@ const plotBaseTemplate = `<?xml version="1.0"?>
 {{end}}
 {{end}}
 
+{{if .Axes.Right}}
+<polyline fill="none" stroke="black" stroke-width="1" points="{{$w}},0 {{$w}},{{$h}}"/>
+{{range .Axes.YRight}}
+{{if .L}}
+<polyline fill="none" stroke="black" stroke-width="1" points="{{sub $w 4}},{{.Y}} {{add $w 4}},{{.Y}}"/>
+<text x="{{add $w 7}}" y="{{.Y}}" text-anchor="start" dominant-baseline="middle">{{.L}}</text>
+{{else}}
+<polyline fill="none" stroke="black" stroke-width="1" points="{{sub $w 2}},{{.Y}} {{add $w 2}},{{.Y}}"/>
+{{end}}
+{{end}}
+<text x="0" y="0" transform="translate({{add $w 55}},{{half $h}}) rotate(90)" text-anchor="middle"  fill="black">{{.Axes.YRlabel}}</text>
+{{end}}
+
 {{if .Axes.XAxisVis}}
-<polyline fill="none" stroke="darkslategrey" stroke-width="1.0" points="-5, {{.Axes.XAxisY}}, 600, {{.Axes.XAxisY}}"/>
+<polyline fill="none" stroke="darkslategrey" stroke-width="1.0" points="-5, {{.Axes.XAxisY}}, {{$w}}, {{.Axes.XAxisY}}"/>
 <g transform="translate(0,{{.Axes.XAxisY}})">
 {{range .Axes.X}}
 {{if .L}}
@@ -119,24 +254,30 @@ const plotBaseTemplate = `<?xml version="1.0"?>
 {{end}}
 </g>
 
-<polyline fill="none" stroke="darkslategrey" stroke-width="1.0" points="0,0 0,174"/>
+<polyline fill="none" stroke="darkslategrey" stroke-width="1.0" points="0,0 0,{{add $h 4}}"/>
 
 {{end}}
 
-<text x="320" y="-15" text-anchor="middle"  font-size="16px"  fill="black">{{.Axes.Title}}</text>
-<text x="0" y="85" transform="rotate(90) translate(85,-25)" text-anchor="middle"  fill="black">{{.Axes.Ylabel}}</text>
-<text x="320" y="208" text-anchor="middle"  font-size="14px" fill="black">Date</text>
+<text x="{{half $w}}" y="-{{add $fs 3}}" text-anchor="middle"  font-size="{{add $fs 4}}px"  fill="black">{{.Axes.Title}}</text>
+<text x="0" y="0" transform="translate(-{{sub .Layout.MarginLeft 10}},{{half $h}}) rotate(90)" text-anchor="middle"  fill="black">{{.Axes.Ylabel}}</text>
+<text x="{{half $w}}" y="{{add $h $fs $fs 14}}" text-anchor="middle"  font-size="{{add $fs 2}}px" fill="black">Date</text>
 {{/* end grid, axes, title */}}
 {{if .Stddev.Show}}
-<rect x="0" y="{{.Stddev.Y}}" width="600" height="{{.Stddev.H}}" fill="gainsboro" opacity="0.5"/>
-<polyline fill="none" stroke="gainsboro" stroke-width="1.0" points="0,{{.Stddev.M}} {{600}},{{.Stddev.M}}"/>
+<rect x="0" y="{{.Stddev.Y}}" width="{{$w}}" height="{{.Stddev.H}}" fill="gainsboro" opacity="0.5"/>
+<polyline fill="none" stroke="gainsboro" stroke-width="1.0" points="0,{{.Stddev.M}} {{$w}},{{.Stddev.M}}"/>
+{{end}}
+{{range .EventPts}}
+<g><title>{{html .L}}</title>
+<polyline fill="none" stroke="darkorange" stroke-width="1.0" stroke-dasharray="4,2" points="{{.X}},0 {{.X}},{{$h}}"/>
+<polygon fill="darkorange" points="-4,-7 4,-7 0,0" transform="translate({{.X}},0)"/>
+</g>
 {{end}}
 {{template "data" .}}
 <circle cx="{{.LastPt.X}}" cy="{{.LastPt.Y}}" r="4" stroke="red" fill="{{if .Fill}}red{{else}}none{{end}}" />
 <circle cx="{{.MinPt.X}}" cy="{{.MinPt.Y}}" r="4" stroke="blue" fill="{{if .Fill}}blue{{else}}none{{end}}" />
 <circle cx="{{.MaxPt.X}}" cy="{{.MaxPt.Y}}" r="4" stroke="blue" fill="{{if .Fill}}blue{{else}}none{{end}}" />
 </g>
-<g transform="translate(690,50)">
+<g transform="translate({{.KeyX}},{{add .Layout.MarginTop 10}})">
 {{range .PlotKey}}
 {{if .Marker.L}}
 {{template "keyMarker" .}}
@@ -146,12 +287,12 @@ const plotBaseTemplate = `<?xml version="1.0"?>
 {{end}}
 {{end}}
 </g>
-{{if not .Last.DateTime.IsZero}}
-<text x="670" y="268" text-anchor="end" font-style="italic">
+{{if and .ShowStats (not .Last.DateTime.IsZero)}}
+<text x="{{add .Layout.MarginLeft $w}}" y="{{sub .Layout.Height 2}}" text-anchor="end" font-style="italic">
 latest: <tspan fill="red">{{ printf "%.2f" .Last.Value}} {{.Unit}}</tspan> ({{date .Last.DateTime}}) min: <tspan fill="blue">{{ printf "%.2f" .Min.Value}}</tspan> ({{date .Min.DateTime}}) max: <tspan fill="blue">{{ printf "%.2f" .Max.Value}}</tspan> ({{date .Max.DateTime}})
 </text>
 {{end}}
-<text x="5" y="268" text-anchor="start">CC BY 3.0 NZ GNS Science</text>
+<text x="5" y="{{sub .Layout.Height 2}}" text-anchor="start">CC BY 3.0 NZ GNS Science</text>
 </svg>
 `
 
@@ -162,12 +303,14 @@ const plotLineTemplate = `
 {{if .HasErrors}}
 <polygon  fill="{{$Colour}}" fill-opacity="0.25" stroke-opacity="0.25" stroke="{{$Colour}}" stroke-width="1" points="{{.Pts.ErrorPoly}}" />
 {{end}}
-<polyline fill="none" stroke="{{$Colour}}" stroke-width="1.0" points="{{range .Pts}}{{.X}},{{.Y}} {{end}}" />
+<polyline fill="none" stroke="{{$Colour}}" stroke-width="1.0" points="{{.Pts.Line}}" />
+{{range .Pts}}{{if .Hollow}}<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="white" stroke="{{$Colour}}"/>{{end}}{{end}}
+{{range .Pts}}{{if .Highlight}}<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="{{if .Hollow}}white{{else}}{{highlight}}{{end}}" stroke="{{highlight}}"/>{{end}}{{end}}
 {{end}}
 {{end}}
 
 {{define "keyMarker"}}
-<polyline fill="{{if .Fill}}{{.Marker.L}}{else}}none{{end}}" stroke="{{.Marker.L}}" stroke-width="3.0" points="-3, {{.Marker.Y}}, 3, {{.Marker.Y}}"/>
+<polyline fill="{{if .Fill}}{{.Marker.L}}{{else}}none{{end}}" stroke="{{.Marker.L}}" stroke-width="3.0" points="-3, {{.Marker.Y}}, 3, {{.Marker.Y}}"/>
 {{end}}
 `
 
@@ -177,7 +320,7 @@ const plotScatterTemplate = `
 {{range .Data}}
 {{$Colour := .Colour}}
 {{if .HasErrors}}{{range .Pts}}<polyline fill="none" stroke="{{$Colour}}" stroke-opacity="0.25" stroke-width="1.0" points="{{.ErrorBar}}"/>{{end}}{{end}}
-{{range .Pts}}<circle cx="{{.X}}" cy="{{.Y}}" r="2" fill="{{if $Fill}}{{$Colour}}{{else}}none{{end}}" stroke="{{$Colour}}"/>{{end}}{{end}}
+{{range .Pts}}<circle cx="{{.X}}" cy="{{.Y}}" r="2" fill="{{if .Hollow}}none{{else if .Highlight}}{{highlight}}{{else if $Fill}}{{$Colour}}{{else}}none{{end}}" stroke="{{if .Highlight}}{{highlight}}{{else}}{{$Colour}}{{end}}"/>{{end}}{{end}}
 {{end}}
 
 {{define "keyMarker"}}
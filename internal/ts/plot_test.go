@@ -0,0 +1,273 @@
+package ts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLayout(t *testing.T) {
+	in := []struct {
+		id        string
+		l         Layout
+		w, h      int
+		err       error
+		svgHeader string
+	}{
+		{id: "default", w: 600, h: 170, svgHeader: `<svg width="800" height="270"`},
+		{id: "small", l: Layout{Width: 400, Height: 200}, w: 200, h: 100, svgHeader: `<svg width="400" height="200"`},
+		{id: "margins", l: Layout{Width: 400, Height: 200, MarginLeft: 10, MarginRight: 10}, w: 380, h: 100, svgHeader: `<svg width="400" height="200"`},
+		{id: "too small", l: Layout{Width: 200, Height: 200}, err: ErrLayout},
+	}
+
+	var p Plot
+	p.AddSeries(Series{Label: "TN1.TEST1", Points: []Point{
+		{DateTime: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), Value: 1.0},
+		{DateTime: time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), Value: 2.0},
+	}})
+
+	for _, v := range in {
+		l, err := v.l.with(plotLayout)
+		if err != v.err {
+			t.Errorf("%s: expected error %v got %v", v.id, v.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if l.DataWidth() != v.w || l.DataHeight() != v.h {
+			t.Errorf("%s: expected data %dx%d got %dx%d", v.id, v.w, v.h, l.DataWidth(), l.DataHeight())
+		}
+
+		var b bytes.Buffer
+		if err = Line.Draw(p, v.l, &b); err != nil {
+			t.Errorf("%s: %s", v.id, err)
+			continue
+		}
+
+		if !strings.Contains(b.String(), v.svgHeader) {
+			t.Errorf("%s: expected svg to contain %s", v.id, v.svgHeader)
+		}
+	}
+}
+
+func TestLogY(t *testing.T) {
+	in := []struct {
+		id         string
+		values     []float64
+		ymin, ymax float64 // set the y axis
+		min, max   float64 // the expected y axis
+		dropped    int
+		labels     []string
+	}{
+		{id: "decades", values: []float64{0.01, 1, 7943.28}, min: 0.01, max: 10000,
+			labels: []string{"0.01", "0.1", "1", "10", "100", "1000", "10000"}},
+		{id: "one decade", values: []float64{20, 50, 80}, min: 10, max: 100,
+			labels: []string{"10", "20", "50", "100"}},
+		{id: "many decades", values: []float64{1e-10, 1, 1e15}, min: 1e-10, max: 1e15,
+			labels: []string{"1e-10", "1e-05", "1", "100000", "1e+10", "1e+15"}},
+		{id: "non-positive", values: []float64{-1, 0, 1, 5}, min: 1, max: 10, dropped: 2,
+			labels: []string{"1", "2", "5", "10"}},
+		{id: "exact decade", values: []float64{100, 100}, min: 100, max: 1000,
+			labels: []string{"100", "200", "500", "1000"}},
+		{id: "fixed", values: []float64{1, 7}, ymin: 0.5, ymax: 20, min: 0.5, max: 20,
+			labels: []string{"0.5", "1", "2", "5", "10", "20"}},
+		{id: "fixed not positive", values: []float64{1, 7}, ymin: -1, ymax: 20, min: 1, max: 10,
+			labels: []string{"1", "2", "5", "10"}},
+		{id: "no data", min: 1, max: 10,
+			labels: []string{"1", "2", "5", "10"}},
+	}
+
+	for _, v := range in {
+		var p Plot
+		var s Series
+
+		for i, y := range v.values {
+			s.Points = append(s.Points, Point{DateTime: time.Date(2000, 1, 1+i, 0, 0, 0, 0, time.UTC), Value: y})
+		}
+
+		p.AddSeries(s)
+		p.SetYLog()
+		p.SetYAxis(v.ymin, v.ymax)
+
+		p.plt.Layout = plotLayout
+		p.plt.width = plotLayout.DataWidth()
+		p.plt.height = plotLayout.DataHeight()
+
+		p.scaleData()
+		p.setAxes()
+
+		if !inRange(p.plt.YMin, v.min, v.min) || !inRange(p.plt.YMax, v.max, v.max) {
+			t.Errorf("%s: expected y axis %g to %g got %g to %g", v.id, v.min, v.max, p.plt.YMin, p.plt.YMax)
+		}
+
+		if p.plt.dropped != v.dropped {
+			t.Errorf("%s: expected %d values dropped got %d", v.id, v.dropped, p.plt.dropped)
+		}
+
+		for i, y := range v.values {
+			if s.Points[i].Value != y {
+				t.Errorf("%s: the series added to the plot was changed", v.id)
+				break
+			}
+		}
+
+		var labels []string
+		last := p.plt.height + 1
+
+		for _, y := range p.plt.Axes.Y {
+			if y.Y < 0 || y.Y > p.plt.height {
+				t.Errorf("%s: tick at %d is off the axis", v.id, y.Y)
+			}
+
+			if y.L == "" {
+				continue
+			}
+
+			if y.Y >= last {
+				t.Errorf("%s: labelled ticks out of order at %s", v.id, y.L)
+			}
+			last = y.Y
+
+			labels = append(labels, y.L)
+		}
+
+		if strings.Join(labels, ",") != strings.Join(v.labels, ",") {
+			t.Errorf("%s: expected labels %v got %v", v.id, v.labels, labels)
+		}
+	}
+}
+
+func TestRightAxis(t *testing.T) {
+	d := func(day int) time.Time {
+		return time.Date(2000, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var p Plot
+	p.AddSeries(Series{Label: "t1", Points: []Point{{DateTime: d(2), Value: 1.0}, {DateTime: d(10), Value: 2.0}}})
+	p.AddRightSeries(Series{Label: "t2", Points: []Point{{DateTime: d(1), Value: 100.0}, {DateTime: d(5), Value: 300.0}}})
+	p.SetUnit("mm")
+	p.SetRightUnit("°C")
+	p.SetRightYLabel("Type 2 (°C)")
+
+	// the x axis is shared and set from the data for both axes.
+	q := p
+	q.plt.width, q.plt.height = 100, 100
+
+	r := q.splitRight()
+	if r == nil {
+		t.Fatal("expected data for the right axis")
+	}
+
+	if len(q.plt.Data) != 1 || len(r.plt.Data) != 1 || !r.plt.Data[0].Right {
+		t.Errorf("expected one series on each axis")
+	}
+
+	if !q.plt.XMin.Equal(d(1)) || !q.plt.XMax.Equal(d(10)) || !r.plt.XMin.Equal(d(1)) || !r.plt.XMax.Equal(d(10)) {
+		t.Errorf("expected x axis from %s to %s got %s to %s and %s to %s", d(1), d(10), q.plt.XMin, q.plt.XMax, r.plt.XMin, r.plt.XMax)
+	}
+
+	// the right axis is scaled on its own data.
+	r.scaleData()
+	if r.plt.YMin != 100 || r.plt.YMax != 300 {
+		t.Errorf("expected right y axis 100 to 300 got %g to %g", r.plt.YMin, r.plt.YMax)
+	}
+
+	var b bytes.Buffer
+	if err := Line.Draw(p, Layout{}, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		`>Type 2 (°C)</text>`,
+		`>left axis (mm)</text>`,
+		`>right axis (°C)</text>`,
+		`<g transform="translate(690,50)">`, // the key is moved right for the right axis labels.
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("expected svg to contain %s", s)
+		}
+	}
+
+	if len(p.plt.Data) != 2 || p.plt.Data[0].Pts != nil {
+		t.Error("drawing the plot changed it")
+	}
+}
+
+func TestLogYErrors(t *testing.T) {
+	in := []struct {
+		id       string
+		log      bool
+		v, e     float64
+		up, down int // expected sizes in svg space.
+	}{
+		{id: "linear", v: 10, e: 5, up: 50, down: 50},
+		{id: "log", log: true, v: 10, e: 5, up: 17, down: 30},            // log10(15/10)*100, log10(10/5)*100
+		{id: "log small error", log: true, v: 100, e: 1, up: 0, down: 0}, // less than a pixel.
+		{id: "log to zero", log: true, v: 10, e: 10, up: 30, down: 100},  // to the bottom of the axis.
+		{id: "log below zero", log: true, v: 10, e: 20, up: 47, down: 100},
+	}
+
+	for _, v := range in {
+		var p Plot
+
+		// a 1 to 1000 axis with 100 px for each decade, or 0 to 30 with 10 px per unit.
+		p.plt.height = 300
+		switch v.log {
+		case true:
+			p.plt.logY = true
+			p.plt.YMin, p.plt.YMax = 1, 1000
+			p.plt.dy = 100
+		default:
+			p.plt.YMin, p.plt.YMax = 0, 30
+			p.plt.dy = 10
+		}
+
+		up, down := p.e(v.v, v.e)
+
+		if up != v.up || down != v.down {
+			t.Errorf("%s: expected error sizes %d up and %d down got %d and %d", v.id, v.up, v.down, up, down)
+		}
+
+		bar := pt{X: 0, Y: p.y(v.v), E: up, ED: down}.ErrorBar()
+		if exp := fmt.Sprintf("0,%d 0,%d", p.y(v.v)+v.down, p.y(v.v)-v.up); bar != exp {
+			t.Errorf("%s: expected error bar %s got %s", v.id, exp, bar)
+		}
+	}
+}
+
+func TestRightAxisLog(t *testing.T) {
+	d := func(day int) time.Time {
+		return time.Date(2000, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var p Plot
+	p.AddSeries(Series{Label: "t1", Points: []Point{{DateTime: d(1), Value: 1.0}, {DateTime: d(2), Value: 100.0}}})
+	p.AddRightSeries(Series{Label: "t2", Points: []Point{{DateTime: d(1), Value: -1.0}, {DateTime: d(2), Value: 20.0}, {DateTime: d(3), Value: 700.0}}})
+	p.SetYLog()
+
+	q := p
+	q.plt.width, q.plt.height = 100, 100
+
+	r := q.splitRight()
+	if r == nil {
+		t.Fatal("expected data for the right axis")
+	}
+
+	r.scaleData()
+	if r.plt.YMin != 10 || r.plt.YMax != 1000 || r.plt.dropped != 1 {
+		t.Errorf("expected right log y axis 10 to 1000 with 1 value dropped got %g to %g with %d", r.plt.YMin, r.plt.YMax, r.plt.dropped)
+	}
+
+	var b bytes.Buffer
+	if err := Line.Draw(p, Layout{}, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), `>not shown: 1</text>`) {
+		t.Error("expected the value dropped from the right axis in the key")
+	}
+}
@@ -0,0 +1,94 @@
+package ts
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*
+Image draws p to b as a PNG or PDF image that looks the same as the SVG from Draw.
+Zero values in l are set from the default layout for the template.
+Returns ErrLayout if l leaves too little room to draw the data.
+*/
+func (s *SVGSpark) Image(p Plot, l Layout, format string, b *bytes.Buffer) error {
+	if err := s.setup(&p, l); err != nil {
+		return err
+	}
+
+	c, err := newCanvas(format, p.plt.Layout.Width, p.plt.Layout.Height)
+	if err != nil {
+		return err
+	}
+
+	img := drawing{c: c}
+	img.rect(0, 0, p.plt.Layout.Width, p.plt.Layout.Height, colour("white"))
+
+	d := img.at(p.plt.Layout.MarginLeft, p.plt.Layout.MarginTop)
+
+	if p.plt.RangeAlert {
+		d.rect(0, 0, p.plt.width, p.plt.height, colour("mistyrose"))
+	}
+
+	p.plt.drawStddev(d)
+	s.data(d, &p.plt)
+	s.label(d, &p.plt)
+
+	return c.encode(b)
+}
+
+// sparkText is the style for the label beside the data.
+func sparkText(p *plt) textStyle {
+	return textStyle{size: float64(p.Layout.FontSize), italic: true, centre: true}
+}
+
+// sparkAllLabel draws as for sparkAllBaseTemplate.
+func sparkAllLabel(d drawing, p *plt) {
+	d.dot(p.LastPt.X, p.LastPt.Y, 3, stroke("red", 1))
+	d.dot(p.MinPt.X, p.MinPt.Y, 3, stroke("blue", 1))
+	d.dot(p.MaxPt.X, p.MaxPt.Y, 3, stroke("blue", 1))
+
+	d.text(p.width+7, p.height/2, sparkText(p), p.stats("black")...)
+}
+
+// sparkLatestLabel draws as for sparkLatestBaseTemplate.
+func sparkLatestLabel(d drawing, p *plt) {
+	d.dot(p.LastPt.X, p.LastPt.Y, 3, stroke("red", 1))
+
+	d.text(p.width+7, p.height/2, sparkText(p),
+		run{s: fmt.Sprintf("%.2f %s", p.Last.Value, p.Unit), c: colour("red")},
+		run{s: fmt.Sprintf(" (%s)", date(p.Last.DateTime)), c: colour("black")},
+	)
+}
+
+// sparkNoneLabel draws as for sparkNoneBaseTemplate, which has no label.
+func sparkNoneLabel(d drawing, p *plt) {}
+
+// sparkLineData draws as for sparkLineTemplate.
+func sparkLineData(d drawing, p *plt) {
+	c := stroke("darkcyan", 1)
+
+	for _, v := range p.Data {
+		d.line(c, v.Pts.line()...)
+
+		for _, pt := range v.Pts {
+			if pt.Hollow {
+				d.dot(pt.X, pt.Y, 1.5, paint{fill: colour("white"), stroke: c.stroke, width: 1})
+			}
+		}
+	}
+}
+
+// sparkScatterData draws as for sparkScatterTemplate.
+func sparkScatterData(d drawing, p *plt) {
+	c := stroke("darkcyan", 1)
+
+	for _, v := range p.Data {
+		for _, pt := range v.Pts {
+			r := 0.5
+			if pt.Hollow {
+				r = 1.5
+			}
+			d.dot(pt.X, pt.Y, r, c)
+		}
+	}
+}
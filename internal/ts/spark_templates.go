@@ -6,13 +6,36 @@ import (
 )
 
 type SVGSpark struct {
-	template      *template.Template // the name for the template must be "plot"
-	width, height int                // for the data on the plot, not the overall size.
+	template *template.Template // the name for the template must be "plot"
+	layout   Layout             // the default layout.
+	// data and label draw the same as the data template and the rest of the base template for Image.
+	data  func(d drawing, p *plt)
+	label func(d drawing, p *plt)
 }
 
-func (s *SVGSpark) Draw(p Plot, b *bytes.Buffer) error {
-	p.plt.width = s.width
-	p.plt.height = s.height
+/*
+Draw draws p to b.  Zero values in l are set from the default layout for the template.
+The right margin is the space for the label.  Returns ErrLayout if l leaves too little
+room to draw the data.
+*/
+func (s *SVGSpark) Draw(p Plot, l Layout, b *bytes.Buffer) error {
+	if err := s.setup(&p, l); err != nil {
+		return err
+	}
+
+	return s.template.ExecuteTemplate(b, "plot", p.plt)
+}
+
+// setup sets the layout and scales the data for drawing p.
+func (s *SVGSpark) setup(p *Plot, l Layout) error {
+	var err error
+
+	if p.plt.Layout, err = l.with(s.layout); err != nil {
+		return err
+	}
+
+	p.plt.width = p.plt.Layout.DataWidth()
+	p.plt.height = p.plt.Layout.DataHeight()
 
 	// don't display error for spark plots.  Set them all zero so they are not included
 	// in the range.
@@ -23,57 +46,95 @@ func (s *SVGSpark) Draw(p Plot, b *bytes.Buffer) error {
 	}
 	p.scaleData()
 
-	return s.template.ExecuteTemplate(b, "plot", p.plt)
+	return nil
 }
 
+// The default layouts for sparks have the data 100x20 px.
+var (
+	sparkAllLayout = Layout{
+		Width:        700,
+		Height:       28,
+		MarginTop:    4,
+		MarginRight:  597,
+		MarginBottom: 4,
+		MarginLeft:   3,
+		FontSize:     14,
+	}
+	sparkLatestLayout = Layout{
+		Width:        280,
+		Height:       28,
+		MarginTop:    4,
+		MarginRight:  177,
+		MarginBottom: 4,
+		MarginLeft:   3,
+		FontSize:     14,
+	}
+	sparkNoneLayout = Layout{
+		Width:        108,
+		Height:       28,
+		MarginTop:    4,
+		MarginRight:  5,
+		MarginBottom: 4,
+		MarginLeft:   3,
+		FontSize:     14,
+	}
+)
+
 var SparkLineAll = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkAllBaseTemplate + sparkStddevTemplate + sparkLineTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkAllLayout,
+	data:     sparkLineData,
+	label:    sparkAllLabel,
 }
 
 var SparkScatterAll = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkAllBaseTemplate + sparkStddevTemplate + sparkScatterTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkAllLayout,
+	data:     sparkScatterData,
+	label:    sparkAllLabel,
 }
 
 var SparkLineLatest = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkLatestBaseTemplate + sparkStddevTemplate + sparkLineTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkLatestLayout,
+	data:     sparkLineData,
+	label:    sparkLatestLabel,
 }
 
 var SparkScatterLatest = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkLatestBaseTemplate + sparkStddevTemplate + sparkScatterTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkLatestLayout,
+	data:     sparkScatterData,
+	label:    sparkLatestLabel,
 }
 
 var SparkLineNone = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkNoneBaseTemplate + sparkStddevTemplate + sparkLineTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkNoneLayout,
+	data:     sparkLineData,
+	label:    sparkNoneLabel,
 }
 
 var SparkScatterNone = SVGSpark{
 	template: template.Must(template.New("plot").Funcs(funcMap).Parse(sparkNoneBaseTemplate + sparkStddevTemplate + sparkScatterTemplate)),
-	width:    100,
-	height:   20,
+	layout:   sparkNoneLayout,
+	data:     sparkScatterData,
+	label:    sparkNoneLabel,
 }
 
 const sparkAllBaseTemplate = `<?xml version="1.0"?>
-<svg width="700" height="28" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="14px" fill="grey">
-<rect x="0" y="0" width="700" height="28" fill="white"/>
-<g transform="translate(3,4)"> 
-{{if .RangeAlert}}<rect x="0" y="0" width="100" height="20" fill="mistyrose"/>{{end}}
-{{template "stddev" .Stddev}}
+{{$w := .Layout.DataWidth}}{{$h := .Layout.DataHeight}}
+<svg width="{{.Layout.Width}}" height="{{.Layout.Height}}" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="{{.Layout.FontSize}}px" fill="grey">
+<rect x="0" y="0" width="{{.Layout.Width}}" height="{{.Layout.Height}}" fill="white"/>
+<g transform="translate({{.Layout.MarginLeft}},{{.Layout.MarginTop}})"> 
+{{if .RangeAlert}}<rect x="0" y="0" width="{{$w}}" height="{{$h}}" fill="mistyrose"/>{{end}}
+{{template "stddev" .}}
 {{template "data" .Data}}
 <circle cx="{{.LastPt.X}}" cy="{{.LastPt.Y}}" r="3" stroke="red" fill="none" />
 <circle cx="{{.MinPt.X}}" cy="{{.MinPt.Y}}" r="3" stroke="blue" fill="none" />
 <circle cx="{{.MaxPt.X}}" cy="{{.MaxPt.Y}}" r="3" stroke="blue" fill="none" />
 </g>
-<text font-style="italic" fill="black" x="110" y="19" text-anchor="start">
+<text font-style="italic" fill="black" x="{{add .Layout.MarginLeft $w 7}}" y="{{add .Layout.MarginTop (half $h)}}" dominant-baseline="middle" text-anchor="start">
 latest: <tspan fill="red">{{ printf "%.2f" .Last.Value}} {{.Unit}}</tspan> ({{date .Last.DateTime}})
 min: <tspan fill="blue">{{ printf "%.2f" .Min.Value}}</tspan> ({{date  .Min.DateTime}})
 max: <tspan fill="blue">{{ printf "%.2f" .Max.Value}}</tspan> ({{date .Max.DateTime}})
@@ -82,37 +143,40 @@ max: <tspan fill="blue">{{ printf "%.2f" .Max.Value}}</tspan> ({{date .Max.DateT
 `
 
 const sparkLatestBaseTemplate = `<?xml version="1.0"?>
-<svg width="280" height="28" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="14px" fill="grey">
-<rect x="0" y="0" width="280" height="28" fill="white"/>
-<g transform="translate(3,4)"> 
-{{if .RangeAlert}}<rect x="0" y="0" width="100" height="20" fill="mistyrose"/>{{end}}
-{{template "stddev" .Stddev}}
+{{$w := .Layout.DataWidth}}{{$h := .Layout.DataHeight}}
+<svg width="{{.Layout.Width}}" height="{{.Layout.Height}}" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="{{.Layout.FontSize}}px" fill="grey">
+<rect x="0" y="0" width="{{.Layout.Width}}" height="{{.Layout.Height}}" fill="white"/>
+<g transform="translate({{.Layout.MarginLeft}},{{.Layout.MarginTop}})"> 
+{{if .RangeAlert}}<rect x="0" y="0" width="{{$w}}" height="{{$h}}" fill="mistyrose"/>{{end}}
+{{template "stddev" .}}
 {{template "data" .Data}}<circle cx="{{.LastPt.X}}" cy="{{.LastPt.Y}}" r="3" stroke="red" fill="none" />
 </g>
-<text font-style="italic" fill="black" x="110" y="19" text-anchor="start"><tspan fill="red">{{ printf "%.2f" .Last.Value}} {{.Unit}}</tspan> ({{date .Last.DateTime}})</text>
+<text font-style="italic" fill="black" x="{{add .Layout.MarginLeft $w 7}}" y="{{add .Layout.MarginTop (half $h)}}" dominant-baseline="middle" text-anchor="start"><tspan fill="red">{{ printf "%.2f" .Last.Value}} {{.Unit}}</tspan> ({{date .Last.DateTime}})</text>
 </svg>	
 `
 
 const sparkNoneBaseTemplate = `<?xml version="1.0"?>
-<svg width="108" height="28" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="14px" fill="grey">
-<rect x="0" y="0" width="108" height="28" fill="white"/>
-<g transform="translate(3,4)"> 
-{{if .RangeAlert}}<rect x="0" y="0" width="100" height="20" fill="mistyrose"/>{{end}}
-{{template "stddev" .Stddev}}
+{{$w := .Layout.DataWidth}}{{$h := .Layout.DataHeight}}
+<svg width="{{.Layout.Width}}" height="{{.Layout.Height}}" xmlns="http://www.w3.org/2000/svg" class="spark" font-family="Arial, sans-serif" font-size="{{.Layout.FontSize}}px" fill="grey">
+<rect x="0" y="0" width="{{.Layout.Width}}" height="{{.Layout.Height}}" fill="white"/>
+<g transform="translate({{.Layout.MarginLeft}},{{.Layout.MarginTop}})"> 
+{{if .RangeAlert}}<rect x="0" y="0" width="{{$w}}" height="{{$h}}" fill="mistyrose"/>{{end}}
+{{template "stddev" .}}
 {{template "data" .Data}}
 </g>
 </svg>	
 `
 
-const sparkStddevTemplate = `{{define "stddev"}}{{if .Show}}
-<rect x="0" y="{{.Y}}" width="100" height="{{.H}}" fill="gainsboro" opacity="0.5"/>
-<polyline fill="none" stroke="gainsboro" stroke-width="1.0" points="0,{{.M}} {{100}},{{.M}}"/>
+const sparkStddevTemplate = `{{define "stddev"}}{{if .Stddev.Show}}
+<rect x="0" y="{{.Stddev.Y}}" width="{{.Layout.DataWidth}}" height="{{.Stddev.H}}" fill="gainsboro" opacity="0.5"/>
+<polyline fill="none" stroke="gainsboro" stroke-width="1.0" points="0,{{.Stddev.M}} {{.Layout.DataWidth}},{{.Stddev.M}}"/>
 {{end}}{{end}}`
 
 const sparkLineTemplate = `{{define "data"}}{{range .}}
-<polyline fill="none" stroke="darkcyan" stroke-width="1.0" points="{{range .Pts}}{{.X}},{{.Y}} {{end}}" />
+<polyline fill="none" stroke="darkcyan" stroke-width="1.0" points="{{.Pts.Line}}" />
+{{range .Pts}}{{if .Hollow}}<circle cx="{{.X}}" cy="{{.Y}}" r="1.5" fill="white" stroke="darkcyan"/>{{end}}{{end}}
 {{end}}{{end}}
 `
 const sparkScatterTemplate = `{{define "data"}}{{range .}}
-{{range .Pts}}<circle cx="{{.X}}" cy="{{.Y}}" r=".5" fill="none" stroke="darkcyan"/>{{end}}{{end}}{{end}}
+{{range .Pts}}<circle cx="{{.X}}" cy="{{.Y}}" r="{{if .Hollow}}1.5{{else}}.5{{end}}" fill="none" stroke="darkcyan"/>{{end}}{{end}}{{end}}
 `
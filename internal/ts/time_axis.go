@@ -0,0 +1,218 @@
+package ts
+
+import (
+	"time"
+)
+
+type timeUnit int
+
+const (
+	minute timeUnit = iota
+	hour
+	day
+	month
+	year
+)
+
+// timeStep is the time between ticks on a time axis.
+type timeStep struct {
+	unit timeUnit
+	n    int
+}
+
+// timeLevel is a step for labelled ticks and the step for unlabelled ticks between them.
+type timeLevel struct {
+	major, minor timeStep
+}
+
+// timeLevels is in order of increasing step.  Steps line up with the start of the next larger unit.
+var timeLevels = []timeLevel{
+	{major: timeStep{minute, 1}},
+	{major: timeStep{minute, 2}, minor: timeStep{minute, 1}},
+	{major: timeStep{minute, 5}, minor: timeStep{minute, 1}},
+	{major: timeStep{minute, 10}, minor: timeStep{minute, 2}},
+	{major: timeStep{minute, 15}, minor: timeStep{minute, 5}},
+	{major: timeStep{minute, 30}, minor: timeStep{minute, 10}},
+	{major: timeStep{hour, 1}, minor: timeStep{minute, 15}},
+	{major: timeStep{hour, 2}, minor: timeStep{minute, 30}},
+	{major: timeStep{hour, 3}, minor: timeStep{hour, 1}},
+	{major: timeStep{hour, 6}, minor: timeStep{hour, 1}},
+	{major: timeStep{hour, 12}, minor: timeStep{hour, 3}},
+	{major: timeStep{day, 1}, minor: timeStep{hour, 6}},
+	{major: timeStep{day, 2}, minor: timeStep{day, 1}},
+	{major: timeStep{day, 5}, minor: timeStep{day, 1}},
+	{major: timeStep{day, 10}, minor: timeStep{day, 1}},
+	{major: timeStep{month, 1}, minor: timeStep{day, 1}},
+	{major: timeStep{month, 2}, minor: timeStep{month, 1}},
+	{major: timeStep{month, 3}, minor: timeStep{month, 1}},
+	{major: timeStep{month, 6}, minor: timeStep{month, 1}},
+	{major: timeStep{year, 1}, minor: timeStep{month, 1}},
+	{major: timeStep{year, 2}, minor: timeStep{year, 1}},
+	{major: timeStep{year, 5}, minor: timeStep{year, 1}},
+	{major: timeStep{year, 10}, minor: timeStep{year, 1}},
+	{major: timeStep{year, 20}, minor: timeStep{year, 5}},
+	{major: timeStep{year, 50}, minor: timeStep{year, 10}},
+	{major: timeStep{year, 100}, minor: timeStep{year, 10}},
+}
+
+// minMinor is the closest in px that minor ticks are drawn.
+const minMinor = 4
+
+// shortest returns the shortest time between ticks for s.
+func (s timeStep) shortest() time.Duration {
+	switch s.unit {
+	case minute:
+		return time.Duration(s.n) * time.Minute
+	case hour:
+		return time.Duration(s.n) * time.Hour
+	case day:
+		return time.Duration(s.n) * 24 * time.Hour
+	case month:
+		return time.Duration(s.n) * 28 * 24 * time.Hour
+	default:
+		return time.Duration(s.n) * 365 * 24 * time.Hour
+	}
+}
+
+// floor returns the time of the tick for s at or before t.
+func (s timeStep) floor(t time.Time) time.Time {
+	t = t.UTC()
+
+	switch s.unit {
+	case minute, hour:
+		return t.Truncate(s.shortest())
+	case day:
+		return time.Date(t.Year(), t.Month(), t.Day()-(t.Day()-1)%s.n, 0, 0, 0, 0, time.UTC)
+	case month:
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%time.Month(s.n), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year()-(t.Year()%s.n+s.n)%s.n, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+/*
+next returns the time of the tick for s after t.  Day ticks start again on the first of each month
+and a tick close to the end of a month is left out so it doesn't crowd the first.
+*/
+func (s timeStep) next(t time.Time) time.Time {
+	switch s.unit {
+	case minute, hour:
+		return t.Add(s.shortest())
+	case day:
+		first := time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		n := t.AddDate(0, 0, s.n)
+		if !n.Before(first.AddDate(0, 0, -s.n/2)) {
+			return first
+		}
+		return n
+	case month:
+		return t.AddDate(0, s.n, 0)
+	default:
+		return t.AddDate(s.n, 0, 0)
+	}
+}
+
+/*
+label returns the label for the tick at t.  Ticks less than a day apart are labelled with
+the time and the date at the start of each day.
+*/
+func (s timeStep) label(t time.Time) string {
+	switch s.unit {
+	case year:
+		return t.Format("2006")
+	case month:
+		return t.Format("2006-01")
+	case day:
+		return t.Format("2006-01-02")
+	}
+
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return t.Format("2006-01-02")
+	}
+
+	return t.Format("15:04")
+}
+
+// labelWidth returns the space needed in px between ticks for s to fit labels in font size fontSize.
+func (s timeStep) labelWidth(fontSize int) float64 {
+	var n int
+
+	switch s.unit {
+	case year:
+		n = 4
+	case month:
+		n = 7
+	case day:
+		n = 10
+	default:
+		// a date at the start of the day next to a time.
+		n = 8
+	}
+
+	return float64(n)*0.6*float64(fontSize) + float64(fontSize)
+}
+
+// ticks returns the times for ticks by s that are between min and max.
+func (s timeStep) ticks(min, max time.Time) []time.Time {
+	var t []time.Time
+
+	for i := s.floor(min); i.Before(max); i = s.next(i) {
+		if i.After(min) {
+			t = append(t, i)
+		}
+	}
+
+	return t
+}
+
+/*
+timeAxis returns ticks for a time axis from min to max that is width px long.  The ticks
+are as close together as the labels allow for fontSize.  Major ticks are labelled and minor
+ticks are not.
+*/
+func timeAxis(min, max time.Time, width, fontSize int) []pt {
+	ticks := make([]pt, 0)
+
+	if !max.After(min) || width <= 0 {
+		return ticks
+	}
+
+	// seconds from min.  time.Sub saturates for spans of more than about 290 years.
+	sec := func(t time.Time) float64 {
+		return float64(t.Unix()-min.Unix()) + float64(t.Nanosecond()-min.Nanosecond())/1e9
+	}
+
+	dx := float64(width) / sec(max)
+
+	l := timeLevels[len(timeLevels)-1]
+
+	for _, v := range timeLevels {
+		if v.major.shortest().Seconds()*dx >= v.major.labelWidth(fontSize) {
+			l = v
+			break
+		}
+	}
+
+	x := func(t time.Time) int {
+		return int((sec(t) * dx) + 0.5)
+	}
+
+	major := make(map[int64]bool)
+
+	for _, t := range l.major.ticks(min, max) {
+		ticks = append(ticks, pt{X: x(t), L: l.major.label(t)})
+		major[t.Unix()] = true
+	}
+
+	if l.minor.n == 0 || l.minor.shortest().Seconds()*dx < minMinor {
+		return ticks
+	}
+
+	for _, t := range l.minor.ticks(min, max) {
+		if !major[t.Unix()] {
+			ticks = append(ticks, pt{X: x(t)})
+		}
+	}
+
+	return ticks
+}
@@ -0,0 +1,98 @@
+package ts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeAxis(t *testing.T) {
+	d := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	in := []struct {
+		id       string
+		min, max time.Time
+		width    int
+		labels   []string
+	}{
+		{id: "centuries", min: d("1600-01-01T00:00:00Z"), max: d("2100-01-01T00:00:00Z"), width: 600,
+			labels: []string{"1650", "1700", "1750", "1800", "1850", "1900", "1950", "2000", "2050"}},
+		{id: "decades", min: d("1990-01-01T00:00:00Z"), max: d("2010-01-01T00:00:00Z"), width: 600,
+			labels: []string{"1992", "1994", "1996", "1998", "2000", "2002", "2004", "2006", "2008"}},
+		{id: "year", min: d("2000-01-01T00:00:00Z"), max: d("2001-01-01T00:00:00Z"), width: 600,
+			labels: []string{"2000-03", "2000-05", "2000-07", "2000-09", "2000-11"}},
+		{id: "year wide", min: d("2000-01-01T00:00:00Z"), max: d("2001-01-01T00:00:00Z"), width: 1200,
+			labels: []string{"2000-02", "2000-03", "2000-04", "2000-05", "2000-06", "2000-07", "2000-08", "2000-09", "2000-10", "2000-11", "2000-12"}},
+		{id: "weeks", min: d("2000-01-01T00:00:00Z"), max: d("2000-02-20T00:00:00Z"), width: 600,
+			labels: []string{"2000-01-11", "2000-01-21", "2000-02-01", "2000-02-11"}},
+		{id: "days", min: d("2010-01-01T00:00:00Z"), max: d("2010-01-03T00:00:00Z"), width: 600,
+			labels: []string{"06:00", "12:00", "18:00", "2010-01-02", "06:00", "12:00", "18:00"}},
+		{id: "hour", min: d("2010-01-01T10:00:00Z"), max: d("2010-01-01T11:00:00Z"), width: 600,
+			labels: []string{"10:10", "10:20", "10:30", "10:40", "10:50"}},
+		{id: "unaligned", min: d("2010-01-01T10:03:20Z"), max: d("2010-01-01T10:13:20Z"), width: 600,
+			labels: []string{"10:04", "10:06", "10:08", "10:10", "10:12"}},
+		{id: "empty", min: d("2010-01-01T00:00:00Z"), max: d("2010-01-01T00:00:00Z"), width: 600},
+		{id: "reversed", min: d("2010-01-02T00:00:00Z"), max: d("2010-01-01T00:00:00Z"), width: 600},
+	}
+
+	for _, v := range in {
+		ticks := timeAxis(v.min, v.max, v.width, 12)
+
+		var labels []string
+		last := -1
+
+		for _, p := range ticks {
+			if p.X < 0 || p.X > v.width {
+				t.Errorf("%s: tick at %d is off the axis", v.id, p.X)
+			}
+
+			if p.L == "" {
+				continue
+			}
+
+			if p.X <= last {
+				t.Errorf("%s: labelled ticks out of order at %s", v.id, p.L)
+			}
+			last = p.X
+
+			labels = append(labels, p.L)
+		}
+
+		if len(labels) != len(v.labels) {
+			t.Errorf("%s: expected labels %v got %v", v.id, v.labels, labels)
+			continue
+		}
+
+		for i := range labels {
+			if labels[i] != v.labels[i] {
+				t.Errorf("%s: expected labels %v got %v", v.id, v.labels, labels)
+				break
+			}
+		}
+	}
+}
+
+func TestTimeStepNext(t *testing.T) {
+	in := []struct {
+		id   string
+		s    timeStep
+		t, e time.Time
+	}{
+		{id: "day 10 mid month", s: timeStep{day, 10}, t: time.Date(2000, 1, 11, 0, 0, 0, 0, time.UTC), e: time.Date(2000, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{id: "day 10 end of month", s: timeStep{day, 10}, t: time.Date(2000, 1, 21, 0, 0, 0, 0, time.UTC), e: time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{id: "day 5 february", s: timeStep{day, 5}, t: time.Date(2000, 2, 26, 0, 0, 0, 0, time.UTC), e: time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{id: "month 3", s: timeStep{month, 3}, t: time.Date(2000, 10, 1, 0, 0, 0, 0, time.UTC), e: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{id: "hour 6", s: timeStep{hour, 6}, t: time.Date(2000, 1, 1, 18, 0, 0, 0, time.UTC), e: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, v := range in {
+		if n := v.s.next(v.t); !n.Equal(v.e) {
+			t.Errorf("%s: expected %s got %s", v.id, v.e, n)
+		}
+	}
+}